@@ -4,29 +4,16 @@ go 1.13
 
 require (
 	github.com/blang/semver v3.5.1+incompatible
-	github.com/docker/distribution v2.7.1+incompatible // indirect
-	github.com/docker/go-metrics v0.0.1 // indirect
-	github.com/emicklei/go-restful v2.9.5+incompatible // indirect
-	github.com/go-openapi/jsonreference v0.19.3 // indirect
-	github.com/go-openapi/spec v0.19.3 // indirect
+	github.com/go-logr/logr v0.2.0
 	github.com/gobuffalo/flect v0.2.1
-	github.com/gogo/protobuf v1.3.1 // indirect
-	github.com/gorilla/mux v1.7.4 // indirect
-	github.com/json-iterator/go v1.1.8 // indirect
-	github.com/mailru/easyjson v0.7.0 // indirect
-	github.com/sirupsen/logrus v1.6.0 // indirect
 	github.com/spf13/afero v1.1.2
 	github.com/spf13/cobra v1.0.0
 	github.com/spf13/pflag v1.0.5
-	go.opencensus.io v0.22.4 // indirect
-	golang.org/x/net v0.0.0-20191004110552-13f9640d40b9 // indirect
+	golang.org/x/text v0.3.2 // indirect
 	golang.org/x/tools v0.0.0-20190920225731-5eefd052ad72
 	gopkg.in/yaml.v2 v2.2.8
 	k8s.io/code-generator v0.15.13-beta.0
 	k8s.io/gengo v0.0.0-20200114144118-36b2048a9120
 	k8s.io/klog v1.0.0
 	k8s.io/klog/v2 v2.3.0
-	k8s.io/kube-openapi v0.0.0-20200410145947-61e04a5be9a6
-	sigs.k8s.io/kubebuilder v1.0.8 // indirect
-	sigs.k8s.io/yaml v1.2.0 // indirect
 )
@@ -0,0 +1,245 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package chain runs the same subcommand of several plugins in order, for the cli and the kubeapi package.
+package chain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/seamounts/kubeapi/internal/cmdutil"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+)
+
+var (
+	_ plugin.GenericSubcommand = &Chain{}
+	_ plugin.DryRunner         = &Chain{}
+	_ plugin.HasRuntime        = &Chain{}
+	_ plugin.HasPresets        = &Chain{}
+)
+
+// Chain runs the same subcommand of several plugins in order, sharing the project configuration.
+type Chain struct {
+	keys        []string
+	subcommands []plugin.GenericSubcommand
+
+	// err is set by BindFlags if the flags of the subcommands collide
+	err error
+}
+
+// New returns the chain of the subcommands of plugins returned by get, skipping the plugins that do not
+// implement it. An error is returned if none of them does.
+func New(plugins []plugin.Base, get func(plugin.Base) plugin.GenericSubcommand, what string) (*Chain, error) {
+	ch := &Chain{}
+	for _, p := range plugins {
+		if sub := get(p); sub != nil {
+			ch.keys = append(ch.keys, plugin.KeyFor(p))
+			ch.subcommands = append(ch.subcommands, sub)
+		}
+	}
+	if len(ch.subcommands) == 0 {
+		return nil, fmt.Errorf("plugins %q do not support %s", strings.Join(Keys(plugins), ","), what)
+	}
+	return ch, nil
+}
+
+// Keys returns the keys of the plugins of the subcommands of the chain, in order.
+func (ch *Chain) Keys() []string {
+	return ch.keys
+}
+
+// Err returns the error of BindFlags if the flags of the subcommands collide.
+func (ch *Chain) Err() error {
+	return ch.err
+}
+
+// UpdateContext implements plugin.GenericSubcommand, appending the help of every subcommand after the first one.
+func (ch *Chain) UpdateContext(ctx *plugin.Context) {
+	base := *ctx
+	ch.subcommands[0].UpdateContext(ctx)
+	for i, sub := range ch.subcommands[1:] {
+		subCtx := base
+		sub.UpdateContext(&subCtx)
+		if subCtx.Description != base.Description {
+			ctx.Description = fmt.Sprintf("%s\n%s:\n%s", ctx.Description, ch.keys[i+1], subCtx.Description)
+		}
+		if subCtx.Examples != base.Examples {
+			ctx.Examples = fmt.Sprintf("%s\n%s", strings.TrimRight(ctx.Examples, " \t\n"), subCtx.Examples)
+		}
+	}
+}
+
+// BindFlags implements plugin.GenericSubcommand. Flags of several subcommands with the same name, type and
+// default value are shared: their value is set for all of them. Other flags with the same name collide.
+func (ch *Chain) BindFlags(fs *pflag.FlagSet) {
+	shared := make(map[string]*sharedValue)
+	for i, sub := range ch.subcommands {
+		subFs := pflag.NewFlagSet(ch.keys[i], pflag.ContinueOnError)
+		sub.BindFlags(subFs)
+		subFs.VisitAll(func(flag *pflag.Flag) {
+			if ch.err != nil {
+				return
+			}
+			if err := ch.addFlag(fs, shared, ch.keys[i], flag); err != nil {
+				ch.err = err
+			}
+		})
+	}
+}
+
+func (ch *Chain) addFlag(fs *pflag.FlagSet, shared map[string]*sharedValue, key string, flag *pflag.Flag) error {
+	if existing := fs.Lookup(flag.Name); existing != nil {
+		value, isShared := shared[flag.Name]
+		if !isShared {
+			return fmt.Errorf("flag --%s of plugin %q collides with a flag of the command", flag.Name, key)
+		}
+		if existing.Value.Type() != flag.Value.Type() || existing.DefValue != flag.DefValue ||
+			existing.Shorthand != flag.Shorthand {
+			return fmt.Errorf("flag --%s of plugin %q collides with the one of plugin %q, which has a "+
+				"different type, default value or shorthand", flag.Name, key, value.keys[0])
+		}
+		value.keys = append(value.keys, key)
+		value.values = append(value.values, flag.Value)
+		return nil
+	}
+	if flag.Shorthand != "" && fs.ShorthandLookup(flag.Shorthand) != nil {
+		return fmt.Errorf("shorthand -%s of flag --%s of plugin %q collides with the one of flag --%s",
+			flag.Shorthand, flag.Name, key, fs.ShorthandLookup(flag.Shorthand).Name)
+	}
+
+	value := &sharedValue{keys: []string{key}, values: []pflag.Value{flag.Value}}
+	shared[flag.Name] = value
+	fs.AddFlag(&pflag.Flag{
+		Name:        flag.Name,
+		Shorthand:   flag.Shorthand,
+		Usage:       flag.Usage,
+		Value:       value,
+		DefValue:    flag.DefValue,
+		NoOptDefVal: flag.NoOptDefVal,
+		Deprecated:  flag.Deprecated,
+		Hidden:      flag.Hidden,
+	})
+	return nil
+}
+
+// InjectConfig implements plugin.GenericSubcommand
+func (ch *Chain) InjectConfig(c *config.Config) {
+	for _, sub := range ch.subcommands {
+		sub.InjectConfig(c)
+	}
+}
+
+// Run implements plugin.GenericSubcommand
+func (ch *Chain) Run() error {
+	for i, sub := range ch.subcommands {
+		if err := sub.Run(); err != nil {
+			if len(ch.subcommands) == 1 {
+				return err
+			}
+			return fmt.Errorf("plugin %q: %w", ch.keys[i], err)
+		}
+	}
+	return nil
+}
+
+// DryRun implements plugin.DryRunner
+func (ch *Chain) DryRun() bool {
+	for _, sub := range ch.subcommands {
+		if cmdutil.IsDryRun(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// InjectRuntime implements plugin.HasRuntime
+func (ch *Chain) InjectRuntime(rt plugin.Runtime) {
+	for _, sub := range ch.subcommands {
+		if hasRuntime, ok := sub.(plugin.HasRuntime); ok {
+			hasRuntime.InjectRuntime(rt)
+		}
+	}
+}
+
+// InjectPresets implements plugin.HasPresets
+func (ch *Chain) InjectPresets(presets []resource.Preset) {
+	for _, sub := range ch.subcommands {
+		if hasPresets, ok := sub.(plugin.HasPresets); ok {
+			hasPresets.InjectPresets(presets)
+		}
+	}
+}
+
+// sharedValue sets the value of the flags with the same name of several subcommands.
+type sharedValue struct {
+	keys   []string
+	values []pflag.Value
+}
+
+// String implements pflag.Value
+func (v *sharedValue) String() string {
+	return v.values[0].String()
+}
+
+// Set implements pflag.Value
+func (v *sharedValue) Set(s string) error {
+	for _, value := range v.values {
+		if err := value.Set(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Type implements pflag.Value
+func (v *sharedValue) Type() string {
+	return v.values[0].Type()
+}
+
+// Keys returns the keys of plugins, in order.
+func Keys(plugins []plugin.Base) []string {
+	keys := make([]string, 0, len(plugins))
+	for _, p := range plugins {
+		keys = append(keys, plugin.KeyFor(p))
+	}
+	return keys
+}
+
+// Lookup returns the first of plugins with the provided key, name or short name, or nil if there is none.
+func Lookup(plugins []plugin.Base, key string) plugin.Base {
+	for _, p := range plugins {
+		if key == plugin.KeyFor(p) || key == p.Name() || key == plugin.GetShortName(p.Name()) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Presets returns the presets of Kinds contributed by plugins, in order.
+func Presets(plugins []plugin.Base) []resource.Preset {
+	var presets []resource.Preset
+	for _, p := range plugins {
+		if provider, ok := p.(plugin.PresetProvider); ok {
+			presets = append(presets, provider.Presets()...)
+		}
+	}
+	return presets
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chain
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+)
+
+// testPlugin is a plugin whose subcommand binds flags and records its runs in ran
+type testPlugin struct {
+	name string
+	// flags are bound by the subcommand
+	flags func(fs *pflag.FlagSet, sub *testSubcommand)
+	// err is returned by the subcommand
+	err error
+	// unsupported plugins do not implement the subcommand
+	unsupported bool
+
+	ran *[]string
+}
+
+func (p testPlugin) Name() string                       { return p.name }
+func (p testPlugin) Version() string                    { return "v1" }
+func (p testPlugin) SupportedProjectVersions() []string { return []string{config.Version1} }
+
+// testSubcommand is the subcommand of a testPlugin
+type testSubcommand struct {
+	plugin testPlugin
+	config *config.Config
+
+	// image is the value of the flag of the same name, if it is bound
+	image string
+}
+
+func (s *testSubcommand) UpdateContext(*plugin.Context) {}
+
+func (s *testSubcommand) BindFlags(fs *pflag.FlagSet) {
+	if s.plugin.flags != nil {
+		s.plugin.flags(fs, s)
+	}
+}
+
+func (s *testSubcommand) InjectConfig(c *config.Config) {
+	s.config = c
+}
+
+func (s *testSubcommand) Run() error {
+	*s.plugin.ran = append(*s.plugin.ran, s.plugin.name)
+	return s.plugin.err
+}
+
+// newTestChain returns the chain of the subcommands of plugins, and the subcommands by plugin name
+func newTestChain(t *testing.T, plugins ...testPlugin) (*Chain, map[string]*testSubcommand) {
+	t.Helper()
+	bases := make([]plugin.Base, 0, len(plugins))
+	for _, p := range plugins {
+		bases = append(bases, p)
+	}
+	subcommands := make(map[string]*testSubcommand)
+	ch, err := New(bases, func(p plugin.Base) plugin.GenericSubcommand {
+		if p.(testPlugin).unsupported {
+			return nil
+		}
+		sub := &testSubcommand{plugin: p.(testPlugin)}
+		subcommands[p.Name()] = sub
+		return sub
+	}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ch, subcommands
+}
+
+func TestRun(t *testing.T) {
+	var ran []string
+	ch, subcommands := newTestChain(t,
+		testPlugin{name: "first", ran: &ran},
+		testPlugin{name: "unsupported", unsupported: true, ran: &ran},
+		testPlugin{name: "second", ran: &ran},
+	)
+	if keys := ch.Keys(); !reflect.DeepEqual(keys, []string{"first/v1", "second/v1"}) {
+		t.Errorf("Keys() = %v", keys)
+	}
+
+	cfg := &config.Config{Version: config.Version1}
+	ch.InjectConfig(cfg)
+	for name, sub := range subcommands {
+		if sub.config != cfg {
+			t.Errorf("the config was not injected into %s", name)
+		}
+	}
+
+	if err := ch.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ran, []string{"first", "second"}) {
+		t.Errorf("the subcommands ran in the order %v", ran)
+	}
+}
+
+func TestRunError(t *testing.T) {
+	failed := errors.New("failed")
+
+	var ran []string
+	ch, _ := newTestChain(t,
+		testPlugin{name: "first", err: failed, ran: &ran},
+		testPlugin{name: "second", ran: &ran},
+	)
+	err := ch.Run()
+	if !errors.Is(err, failed) || !strings.HasPrefix(err.Error(), `plugin "first/v1": `) {
+		t.Errorf("unexpected error %v", err)
+	}
+	// The subcommands after the failed one do not run
+	if !reflect.DeepEqual(ran, []string{"first"}) {
+		t.Errorf("the subcommands ran in the order %v", ran)
+	}
+
+	// The error of a single subcommand is not prefixed with its plugin
+	ch, _ = newTestChain(t, testPlugin{name: "first", err: failed, ran: &ran})
+	if err := ch.Run(); err != failed {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestNewUnsupported(t *testing.T) {
+	_, err := New([]plugin.Base{testPlugin{name: "first", unsupported: true}}, func(plugin.Base) plugin.GenericSubcommand {
+		return nil
+	}, "create api")
+	if err == nil || err.Error() != `plugins "first/v1" do not support create api` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+// bindImage binds the image flag with the provided default value and shorthand
+func bindImage(defaultValue, shorthand string) func(*pflag.FlagSet, *testSubcommand) {
+	return func(fs *pflag.FlagSet, sub *testSubcommand) {
+		fs.StringVarP(&sub.image, "image", shorthand, defaultValue, "image")
+	}
+}
+
+func TestBindFlags(t *testing.T) {
+	for name, test := range map[string]struct {
+		first, second func(*pflag.FlagSet, *testSubcommand)
+		// command binds the flags of the command itself
+		command func(*pflag.FlagSet)
+		err     string
+	}{
+		"shared flag": {
+			first:  bindImage("nginx", "i"),
+			second: bindImage("nginx", "i"),
+		},
+		"different default value": {
+			first:  bindImage("nginx", ""),
+			second: bindImage("busybox", ""),
+			err:    `flag --image of plugin "second/v1" collides with the one of plugin "first/v1"`,
+		},
+		"different type": {
+			first: bindImage("nginx", ""),
+			second: func(fs *pflag.FlagSet, _ *testSubcommand) {
+				fs.Bool("image", false, "image")
+			},
+			err: `flag --image of plugin "second/v1" collides with the one of plugin "first/v1"`,
+		},
+		"command flag": {
+			first:   bindImage("nginx", ""),
+			command: func(fs *pflag.FlagSet) { fs.String("image", "", "image") },
+			err:     `flag --image of plugin "first/v1" collides with a flag of the command`,
+		},
+		"shorthand": {
+			first: bindImage("nginx", "i"),
+			second: func(fs *pflag.FlagSet, _ *testSubcommand) {
+				fs.StringP("input", "i", "", "input")
+			},
+			err: `shorthand -i of flag --input of plugin "second/v1" collides with the one of flag --image`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var ran []string
+			ch, subcommands := newTestChain(t,
+				testPlugin{name: "first", flags: test.first, ran: &ran},
+				testPlugin{name: "second", flags: test.second, ran: &ran},
+			)
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			if test.command != nil {
+				test.command(fs)
+			}
+			ch.BindFlags(fs)
+
+			if test.err != "" {
+				if err := ch.Err(); err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("expected an error with %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err := ch.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			// The value of the shared flag is set for every subcommand
+			if err := fs.Parse([]string{"-i", "busybox"}); err != nil {
+				t.Fatal(err)
+			}
+			for name, sub := range subcommands {
+				if sub.image != "busybox" {
+					t.Errorf("the image of %s is %q", name, sub.image)
+				}
+			}
+		})
+	}
+}
@@ -17,6 +17,10 @@ limitations under the License.
 package cmdutil
 
 import (
+	"fmt"
+
+	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/plugin"
 	"github.com/seamounts/kubeapi/pkg/scaffold"
 )
 
@@ -31,8 +35,17 @@ type RunOptions interface {
 	PostScaffold() error
 }
 
-// Run executes a command
-func Run(options RunOptions) error {
+// Run executes a command in rt, scaffolding the project files through its file system
+// If options is a plugin.DryRunner asking for a dry run, the changes are printed instead of made
+func Run(options RunOptions, rt plugin.Runtime) error {
+	if dryRunner, isDryRunner := options.(plugin.DryRunner); isDryRunner && dryRunner.DryRun() {
+		if err := dryRun(options, rt); err != nil {
+			return err
+		}
+		fmt.Fprintln(rt.Stdout, "Skipping the remaining steps (e.g. code generation) of the dry run")
+		return nil
+	}
+
 	// Step 1: validate
 	if err := options.Validate(); err != nil {
 		return err
@@ -43,6 +56,8 @@ func Run(options RunOptions) error {
 	if err != nil {
 		return err
 	}
+	scaffolder.InjectFS(file.Filesystem{FS: rt.FS, Record: rt.RecordFile})
+
 	// Step 3: scaffold
 	if err := scaffolder.Scaffold(); err != nil {
 		return err
@@ -0,0 +1,192 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmdutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/afero"
+
+	"github.com/seamounts/kubeapi/internal/diff"
+	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+	"github.com/seamounts/kubeapi/pkg/scaffold"
+)
+
+// skippedDirs are not copied to the in-memory file system of dry runs
+var skippedDirs = map[string]struct{}{
+	".git":   {},
+	"vendor": {},
+}
+
+// dryRun executes a command against an in-memory copy of the project files of rt and writes to its
+// standard output the changes it would make, without making them. The changes are recorded in rt.
+// Merge conflicts are returned once the merged files, with their conflict markers, are printed.
+// The command is not finished (step 4).
+func dryRun(options RunOptions, rt plugin.Runtime) error {
+	// Step 1: validate
+	if err := options.Validate(); err != nil {
+		return err
+	}
+
+	// Step 2: get scaffolder
+	scaffolder, err := options.GetScaffolder()
+	if err != nil {
+		return err
+	}
+
+	memFs, err := copyProject(rt.FS)
+	if err != nil {
+		return err
+	}
+	recorded := make(map[string]file.Change)
+	scaffolder.InjectFS(file.Filesystem{
+		FS: memFs,
+		Record: func(change file.Change) {
+			recorded[change.Path] = change
+		},
+	})
+
+	// Step 3: scaffold
+	// The merge conflicts are printed in the diffs of the merged files before they are returned
+	scaffoldErr := scaffolder.Scaffold()
+	if scaffoldErr != nil && !scaffold.IsMergeConflictError(scaffoldErr) {
+		return scaffoldErr
+	}
+
+	changes, err := compare(rt.FS, memFs, recorded)
+	if err != nil {
+		return err
+	}
+	if rt.RecordFile != nil {
+		for _, change := range changes {
+			rt.RecordFile(change)
+		}
+	}
+	printChanges(rt.Stdout, changes)
+
+	return scaffoldErr
+}
+
+// copyProject returns an in-memory file system with a copy of the project files
+func copyProject(osFs afero.Fs) (afero.Fs, error) {
+	memFs := afero.NewMemMapFs()
+	err := walkProject(osFs, func(path string) error {
+		content, err := afero.ReadFile(osFs, path)
+		if err != nil {
+			return err
+		}
+		return afero.WriteFile(memFs, path, content, 0600)
+	})
+
+	return memFs, err
+}
+
+// compare returns the changes made to the project files in memFs, preferring the ones recorded
+// by the scaffold machinery as they also describe the skipped files
+func compare(osFs, memFs afero.Fs, recorded map[string]file.Change) ([]file.Change, error) {
+	changes := make(map[string]file.Change, len(recorded))
+	for path, change := range recorded {
+		changes[path] = change
+	}
+
+	// Files created or updated without the scaffold machinery
+	if err := walkProject(memFs, func(path string) error {
+		if _, found := changes[path]; found {
+			return nil
+		}
+		contents, err := afero.ReadFile(memFs, path)
+		if err != nil {
+			return err
+		}
+		exists, err := afero.Exists(osFs, path)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			changes[path] = file.Change{Path: path, Action: file.Created, Contents: string(contents)}
+			return nil
+		}
+		previous, err := afero.ReadFile(osFs, path)
+		if err != nil {
+			return err
+		}
+		if string(previous) != string(contents) {
+			changes[path] = file.Change{Path: path, Action: file.Updated,
+				Previous: string(previous), Contents: string(contents)}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// Files removed
+	if err := walkProject(osFs, func(path string) error {
+		exists, err := afero.Exists(memFs, path)
+		if err != nil || exists {
+			return err
+		}
+		changes[path] = file.Change{Path: path, Action: file.Deleted}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sorted := make([]file.Change, 0, len(changes))
+	for _, change := range changes {
+		sorted = append(sorted, change)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	return sorted, nil
+}
+
+func printChanges(out io.Writer, changes []file.Change) {
+	fmt.Fprintln(out, "Dry run, the following changes would be made:")
+	for _, change := range changes {
+		if change.Action == file.Unchanged {
+			continue
+		}
+		fmt.Fprintf(out, "  %-9s %s\n", change.Action, change.Path)
+	}
+
+	for _, change := range changes {
+		if change.Action == file.Updated {
+			fmt.Fprint(out, "\n", diff.Unified(change.Path, change.Previous, change.Contents))
+		}
+	}
+}
+
+// walkProject calls fn with the path of every project file in fs
+func walkProject(fs afero.Fs, fn func(path string) error) error {
+	return afero.Walk(fs, ".", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if _, skip := skippedDirs[info.Name()]; skip {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(path)
+	})
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmdutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/seamounts/kubeapi/internal/config"
+	"github.com/seamounts/kubeapi/internal/transaction"
+	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+	"github.com/seamounts/kubeapi/pkg/scaffold"
+)
+
+// RunInTransaction runs gsub in rt and saves cfg in a transaction, so that every project file touched by
+// gsub or cfg is restored if either of them fails or ctx is done before they complete. Errors of gsub are
+// prefixed with msg. It returns the project files that were created, updated or deleted.
+// Merge conflicts do not restore the project files: their changes are returned along with the conflict error.
+// Dry runs are not saved and do not return any change.
+// The changes of previous commands that were interrupted are rolled back first.
+// Subcommands that implement plugin.HasRuntime run in rt, and only their changes can be restored.
+func RunInTransaction(ctx context.Context, rt plugin.Runtime, cfg *config.Config, gsub plugin.GenericSubcommand,
+	msg string) ([]file.Change, error) {
+	recovered, err := transaction.Recover(rt.FS)
+	if err != nil {
+		return nil, err
+	}
+	if recovered != 0 {
+		fmt.Fprintf(rt.Stdout, "Rolled back the changes of %d interrupted command(s)\n", recovered)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if IsDryRun(gsub) {
+		injectRuntime(gsub, rt)
+		if err := gsub.Run(); err != nil {
+			return nil, fmt.Errorf("%s: %w", msg, err)
+		}
+		return nil, nil
+	}
+
+	tx, err := transaction.Begin(rt.FS)
+	if err != nil {
+		return nil, err
+	}
+	txRt := rt
+	txRt.FS = tx.Fs()
+	injectRuntime(gsub, txRt)
+	cfg.InjectFS(txRt.FS)
+
+	runErr := gsub.Run()
+	if runErr != nil {
+		runErr = fmt.Errorf("%s: %w", msg, runErr)
+	}
+
+	// Merge conflicts are committed, so that the user can resolve the conflict markers of the merged files
+	var changes []file.Change
+	if runErr != nil && !scaffold.IsMergeConflictError(runErr) {
+		err = runErr
+	} else if err = ctx.Err(); err == nil {
+		if err = cfg.Save(); err == nil {
+			changes, err = tx.Changes()
+		}
+	}
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return nil, fmt.Errorf("%v\n%v", err, rollbackErr)
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return changes, runErr
+}
+
+// injectRuntime passes rt to gsub if it runs in a runtime provided by the caller.
+func injectRuntime(gsub plugin.GenericSubcommand, rt plugin.Runtime) {
+	if hasRuntime, ok := gsub.(plugin.HasRuntime); ok {
+		hasRuntime.InjectRuntime(rt)
+	}
+}
+
+// IsDryRun returns true if gsub was asked not to make any change.
+func IsDryRun(gsub plugin.GenericSubcommand) bool {
+	dryRunner, isDryRunner := gsub.(plugin.DryRunner)
+	return isDryRunner && dryRunner.DryRun()
+}
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/afero"
 
@@ -70,6 +71,31 @@ func readFrom(fs afero.Fs, path string) (c config.Config, err error) {
 	return
 }
 
+// FindRoot returns the closest directory to dir, either dir or one of its parents, that contains the
+// configuration file at the default path. It returns dir if none does, e.g. before the project is initialized.
+func FindRoot(fs afero.Fs, dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for current := dir; ; {
+		found, err := exists(fs, filepath.Join(current, DefaultPath))
+		if err != nil {
+			return "", err
+		}
+		if found {
+			return current, nil
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dir, nil
+		}
+		current = parent
+	}
+}
+
 // Read obtains the configuration from the default path but doesn't allow to persist changes
 func Read() (*config.Config, error) {
 	return ReadFrom(DefaultPath)
@@ -77,7 +103,12 @@ func Read() (*config.Config, error) {
 
 // ReadFrom obtains the configuration from the provided path but doesn't allow to persist changes
 func ReadFrom(path string) (*config.Config, error) {
-	c, err := readFrom(afero.NewOsFs(), path)
+	return ReadFromFs(afero.NewOsFs(), path)
+}
+
+// ReadFromFs obtains the configuration from the provided path of fs but doesn't allow to persist changes
+func ReadFromFs(fs afero.Fs, path string) (*config.Config, error) {
+	c, err := readFrom(fs, path)
 	return &c, err
 }
 
@@ -91,7 +122,7 @@ type Config struct {
 	path string
 	// mustNotExist requires the file not to exist when saving it
 	mustNotExist bool
-	// fs is for testing.
+	// fs is the file system the configuration is saved to
 	fs afero.Fs
 }
 
@@ -115,16 +146,26 @@ func Load() (*Config, error) {
 // LoadInitialized calls Load() but returns helpful error messages if the config
 // does not exist.
 func LoadInitialized() (*Config, error) {
-	c, err := Load()
+	return LoadInitializedFromFs(afero.NewOsFs())
+}
+
+// LoadInitializedFromFs obtains the configuration from the default path of fs allowing to persist changes,
+// but returns helpful error messages if the config does not exist.
+func LoadInitializedFromFs(fs afero.Fs) (*Config, error) {
+	c, err := LoadFromFs(fs, DefaultPath)
 	if os.IsNotExist(err) {
-		return nil, errors.New("unable to find configuration file, project must be initialized")
+		return nil, notInitializedError{}
 	}
 	return c, err
 }
 
 // LoadFrom obtains the configuration from the provided path allowing to persist changes (Save method)
 func LoadFrom(path string) (*Config, error) {
-	fs := afero.NewOsFs()
+	return LoadFromFs(afero.NewOsFs(), path)
+}
+
+// LoadFromFs obtains the configuration from the provided path of fs allowing to persist changes (Save method)
+func LoadFromFs(fs afero.Fs, path string) (*Config, error) {
 	c, err := readFrom(fs, path)
 	return &Config{Config: c, path: path, fs: fs}, err
 }
@@ -167,11 +208,47 @@ func (c Config) Save() error {
 	return nil
 }
 
+// InjectFS sets the file system the configuration is saved to
+func (c *Config) InjectFS(fs afero.Fs) {
+	c.fs = fs
+}
+
 // Path returns the path for configuration file
 func (c Config) Path() string {
 	return c.path
 }
 
+// notInitializedError is returned if the configuration file does not exist
+type notInitializedError struct{}
+
+// Error implements error interface
+func (notInitializedError) Error() string {
+	return "unable to find configuration file, project must be initialized"
+}
+
+// IsNotInitializedError checks if the returned error is because the project was not initialized
+func IsNotInitializedError(err error) bool {
+	return errors.As(err, &notInitializedError{})
+}
+
+// alreadyInitializedError is returned if the configuration file exists when initializing a project
+type alreadyInitializedError struct{}
+
+// Error implements error interface
+func (alreadyInitializedError) Error() string {
+	return "config already initialized"
+}
+
+// AlreadyInitializedError returns the error of initializing a project whose configuration file exists
+func AlreadyInitializedError() error {
+	return alreadyInitializedError{}
+}
+
+// IsAlreadyInitializedError checks if the returned error is because the project was already initialized
+func IsAlreadyInitializedError(err error) bool {
+	return errors.As(err, &alreadyInitializedError{})
+}
+
 type saveError struct {
 	err error
 }
@@ -0,0 +1,182 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package diff compares text files line by line.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change in unified diffs
+const context = 3
+
+// SplitLines splits s into lines that keep their line terminator
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Match returns, for every line of a, the index of the line of b it is matched to by their
+// longest common subsequence, or -1 if it is not part of it
+func Match(a, b []string) []int {
+	// lengths[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] >= lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	matches := make([]int, len(a))
+	i, j := 0, 0
+	for i < len(a) {
+		switch {
+		case j < len(b) && a[i] == b[j]:
+			matches[i] = j
+			i, j = i+1, j+1
+		case j < len(b) && lengths[i+1][j] < lengths[i][j+1]:
+			j++
+		default:
+			matches[i] = -1
+			i++
+		}
+	}
+	return matches
+}
+
+// op is a line of an edit script
+type op struct {
+	kind byte // ' ', '-' or '+'
+	line string
+	// a and b are the line numbers in each file before this line
+	a, b int
+}
+
+// editScript returns the lines that are kept, removed from a and added from b to turn a into b
+func editScript(a, b []string) []op {
+	matches := Match(a, b)
+
+	var ops []op
+	j := 0
+	for i, line := range a {
+		if matches[i] == -1 {
+			ops = append(ops, op{'-', line, i, j})
+			continue
+		}
+		for ; j < matches[i]; j++ {
+			ops = append(ops, op{'+', b[j], i, j})
+		}
+		ops = append(ops, op{' ', line, i, j})
+		j++
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{'+', b[j], len(a), j})
+	}
+	return ops
+}
+
+// Unified returns the unified diff that turns a into b, or an empty string if they are equal
+func Unified(path, a, b string) string {
+	if a == b {
+		return ""
+	}
+	aLines, bLines := SplitLines(a), SplitLines(b)
+	ops := editScript(aLines, bLines)
+
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "--- a/%s\n+++ b/%s\n", path, path)
+
+	for start := 0; start < len(ops); {
+		// Find the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Extend the hunk while changes are close enough to share their context
+		end, unchanged := start, 0
+		for i := start; i < len(ops) && unchanged <= 2*context; i++ {
+			if ops[i].kind == ' ' {
+				unchanged++
+				continue
+			}
+			end, unchanged = i+1, 0
+		}
+
+		from, to := start-context, end+context
+		if from < 0 {
+			from = 0
+		}
+		if to > len(ops) {
+			to = len(ops)
+		}
+		writeHunk(out, ops[from:to])
+		start = to
+	}
+
+	return out.String()
+}
+
+func writeHunk(out *strings.Builder, ops []op) {
+	aLen, bLen := 0, 0
+	for _, o := range ops {
+		if o.kind != '+' {
+			aLen++
+		}
+		if o.kind != '-' {
+			bLen++
+		}
+	}
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(ops[0].a, aLen), hunkRange(ops[0].b, bLen))
+
+	for _, o := range ops {
+		out.WriteByte(o.kind)
+		out.WriteString(o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/seamounts/kubeapi/internal/diff"
+	"github.com/seamounts/kubeapi/pkg/scaffoldtest"
+)
+
+// numbered returns a file with a line for each number from 1 to n, with the lines in changed replaced
+func numbered(n int, changed map[int]string) string {
+	out := &strings.Builder{}
+	for i := 1; i <= n; i++ {
+		if line, found := changed[i]; found {
+			out.WriteString(line + "\n")
+			continue
+		}
+		fmt.Fprintf(out, "line %d\n", i)
+	}
+	return out.String()
+}
+
+func TestUnified(t *testing.T) {
+	diffs := make(map[string]string)
+	for name, test := range map[string]struct {
+		a, b string
+	}{
+		// The changes of lines 3 and 10 share a hunk, the one of line 18 is too far from them
+		"context-lines": {
+			a: numbered(20, nil),
+			b: numbered(20, map[int]string{3: "line three", 10: "line ten", 18: "line eighteen"}),
+		},
+		"new-file": {
+			b: "package v1\n\nconst Version = \"v1\"\n",
+		},
+		"deleted-lines": {
+			a: numbered(5, nil),
+			b: numbered(2, nil),
+		},
+		"added-trailing-newline": {
+			a: "package v1\n\nconst Version = \"v1\"",
+			b: "package v1\n\nconst Version = \"v1\"\n",
+		},
+		"without-trailing-newline": {
+			a: "package v1\n\nconst Version = \"v1\"",
+			b: "package v1\n\nconst Version = \"v2\"",
+		},
+	} {
+		diffs[name+".diff"] = diff.Unified("apis/ship/v1/doc.go", test.a, test.b)
+	}
+	scaffoldtest.AssertGolden(t, "testdata", diffs)
+
+	if unified := diff.Unified("doc.go", "package v1\n", "package v1\n"); unified != "" {
+		t.Errorf("unexpected diff of equal files:\n%s", unified)
+	}
+}
@@ -0,0 +1,543 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package transaction makes the changes of a command to the project files all-or-nothing.
+//
+// Every file written through a transaction is first staged in a temporary directory and then
+// renamed into place, so that no file is ever left half written. The original content of every
+// touched file is kept in the staging directory until the transaction is committed, so that a
+// rollback can restore the project to its state before the command.
+//
+// The journal of a transaction is kept on disk, so that a transaction that was interrupted, e.g. because
+// the process exited, can be rolled back by the next command (see Recover). The staging directory is named
+// after the host and the process of the transaction, so that only the ones of processes that are no longer
+// running are rolled back.
+package transaction
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/seamounts/kubeapi/pkg/model/file"
+)
+
+const (
+	// stagingParent is the directory where staging directories are created
+	stagingParent = ".kubeapi"
+	// stagingPrefix is the prefix of the name of staging directories, which is followed by
+	// <host>-<pid>- of the process of the transaction and a random suffix
+	stagingPrefix = "transaction-"
+	// journalFile is the name of the journal of a transaction in its staging directory
+	journalFile = "journal"
+
+	writeFlags = os.O_WRONLY | os.O_RDWR | os.O_APPEND | os.O_CREATE | os.O_TRUNC
+)
+
+// Transaction journals the changes made through its file system
+type Transaction struct {
+	fs  afero.Fs
+	dir string
+	// createdParent is true if the staging parent directory was created by the transaction
+	createdParent bool
+
+	// touched stores, for each touched file, where its original content was saved,
+	// or an empty string if it did not exist
+	touched map[string]string
+	// createdDirs are the directories that did not exist before the transaction, in creation order
+	createdDirs []string
+
+	// staged is used to name staged files
+	staged int
+}
+
+// Begin starts a new transaction over fs
+func Begin(fs afero.Fs) (*Transaction, error) {
+	parentExists, err := afero.DirExists(fs, stagingParent)
+	if err != nil {
+		return nil, transactionError{"begin", err}
+	}
+	if err := fs.MkdirAll(stagingParent, 0700); err != nil {
+		return nil, transactionError{"begin", err}
+	}
+	// The owner is part of the name of the directory, so that it is known as soon as the directory exists
+	dir, err := afero.TempDir(fs, stagingParent, fmt.Sprintf("%s%s-%d-", stagingPrefix, hostname(), os.Getpid()))
+	if err != nil {
+		return nil, transactionError{"begin", err}
+	}
+
+	return &Transaction{
+		fs:            fs,
+		dir:           dir,
+		createdParent: !parentExists,
+		touched:       make(map[string]string),
+	}, nil
+}
+
+// Fs returns the file system that the changes of the transaction must be made through
+func (t *Transaction) Fs() afero.Fs {
+	return &journalFs{Fs: t.fs, tx: t}
+}
+
+// Changes returns the files created, updated or deleted through the transaction so far, sorted by path.
+// Files that were rewritten with their original content are not returned.
+func (t *Transaction) Changes() ([]file.Change, error) {
+	paths := make([]string, 0, len(t.touched))
+	for path := range t.touched {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	changes := make([]file.Change, 0, len(paths))
+	for _, path := range paths {
+		exists, err := afero.Exists(t.fs, path)
+		if err != nil {
+			return nil, err
+		}
+		backup := t.touched[path]
+		switch {
+		case backup == "" && exists:
+			changes = append(changes, file.Change{Path: path, Action: file.Created})
+		case backup != "" && !exists:
+			changes = append(changes, file.Change{Path: path, Action: file.Deleted})
+		case backup != "":
+			previous, err := afero.ReadFile(t.fs, backup)
+			if err != nil {
+				return nil, err
+			}
+			contents, err := afero.ReadFile(t.fs, path)
+			if err != nil {
+				return nil, err
+			}
+			if string(previous) != string(contents) {
+				changes = append(changes, file.Change{Path: path, Action: file.Updated})
+			}
+		}
+	}
+	return changes, nil
+}
+
+// Commit keeps every change made through the transaction
+func (t *Transaction) Commit() error {
+	if err := t.removeStaging(); err != nil {
+		return transactionError{"commit", err}
+	}
+	return nil
+}
+
+// Rollback restores every file touched by the transaction to its original content
+func (t *Transaction) Rollback() error {
+	paths := make([]string, 0, len(t.touched))
+	for path := range t.touched {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var failed []string
+	for _, path := range paths {
+		if err := t.restore(path); err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%v)", path, err))
+		}
+	}
+
+	// Remove the directories created by the transaction, deepest first, if they are empty
+	for i := len(t.createdDirs) - 1; i >= 0; i-- {
+		if empty, err := afero.IsEmpty(t.fs, t.createdDirs[i]); err == nil && empty {
+			_ = t.fs.Remove(t.createdDirs[i])
+		}
+	}
+
+	if len(failed) != 0 {
+		return transactionError{"rollback", fmt.Errorf("unable to restore %s, their original content "+
+			"is kept in %s", strings.Join(failed, ", "), t.dir)}
+	}
+	if err := t.removeStaging(); err != nil {
+		return transactionError{"rollback", err}
+	}
+	return nil
+}
+
+// removeStaging removes the staging directory, and its parent if it was created for it and is empty
+func (t *Transaction) removeStaging() error {
+	if err := t.fs.RemoveAll(t.dir); err != nil {
+		return err
+	}
+	if t.createdParent {
+		if empty, err := afero.IsEmpty(t.fs, stagingParent); err == nil && empty {
+			return t.fs.Remove(stagingParent)
+		}
+	}
+	return nil
+}
+
+func (t *Transaction) restore(path string) error {
+	backup := t.touched[path]
+	if backup == "" {
+		if err := t.fs.RemoveAll(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := t.fs.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return t.fs.Rename(backup, path)
+}
+
+// backup saves the original content of path, the first time it is touched
+func (t *Transaction) backup(path string) error {
+	path = filepath.Clean(path)
+	if _, found := t.touched[path]; found {
+		return nil
+	}
+
+	info, err := t.fs.Stat(path)
+	if os.IsNotExist(err) {
+		t.touched[path] = ""
+		return t.journal(entry{Path: path})
+	}
+	if err != nil {
+		return err
+	}
+
+	// Directories are journaled file by file
+	if info.IsDir() {
+		return afero.Walk(t.fs, path, func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			return t.backup(p)
+		})
+	}
+
+	content, err := afero.ReadFile(t.fs, path)
+	if err != nil {
+		return err
+	}
+	backup := t.nextStagingPath()
+	if err := afero.WriteFile(t.fs, backup, content, info.Mode()); err != nil {
+		return err
+	}
+	t.touched[path] = backup
+
+	return t.journal(entry{Path: path, Backup: backup})
+}
+
+// mkdirAll creates path and its missing parents, remembering which ones were created
+func (t *Transaction) mkdirAll(path string, perm os.FileMode) error {
+	var missing []string
+	for dir := filepath.Clean(path); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		exists, err := afero.DirExists(t.fs, dir)
+		if err != nil {
+			return err
+		}
+		if exists {
+			break
+		}
+		missing = append(missing, dir)
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		t.createdDirs = append(t.createdDirs, missing[i])
+		if err := t.journal(entry{Path: missing[i], Dir: true}); err != nil {
+			return err
+		}
+	}
+	return t.fs.MkdirAll(path, perm)
+}
+
+// entry is a line of the journal of a transaction
+type entry struct {
+	// Path is the touched path
+	Path string `json:"path"`
+	// Backup is where the original content of the file at Path is saved, empty if it did not exist
+	Backup string `json:"backup,omitempty"`
+	// Dir is true if Path is a directory created by the transaction
+	Dir bool `json:"dir,omitempty"`
+}
+
+// journal appends e to the journal of the transaction, before the change it describes is made
+func (t *Transaction) journal(e entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := t.fs.OpenFile(filepath.Join(t.dir, journalFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Recover rolls back the transactions over fs that were interrupted before being committed or rolled
+// back, which are the ones of processes of this host that are no longer running. The transactions of
+// running processes, including this one, and of other hosts are left to them. It returns the number of
+// transactions that were rolled back.
+func Recover(fs afero.Fs) (int, error) {
+	dirs, err := afero.Glob(fs, filepath.Join(stagingParent, stagingPrefix+"*"))
+	if err != nil {
+		return 0, transactionError{"recover", err}
+	}
+
+	recovered := 0
+	for _, dir := range dirs {
+		if !isStale(filepath.Base(dir)) {
+			continue
+		}
+		t, err := load(fs, dir)
+		if err != nil {
+			return recovered, transactionError{"recover", err}
+		}
+		if err := t.Rollback(); err != nil {
+			return recovered, err
+		}
+		recovered++
+	}
+	return recovered, nil
+}
+
+// isStale returns true if the transaction staged in the directory with the provided name was interrupted,
+// i.e. its process ran on this host and is no longer running. Directories without owner are stale.
+func isStale(name string) bool {
+	// The host may contain dashes, unlike the pid and the random suffix
+	owner := strings.Split(strings.TrimPrefix(name, stagingPrefix), "-")
+	if len(owner) < 3 {
+		return true
+	}
+	host := strings.Join(owner[:len(owner)-2], "-")
+	pid, err := strconv.Atoi(owner[len(owner)-2])
+	if err != nil || pid <= 0 {
+		return true
+	}
+	return host == hostname() && !isRunning(pid)
+}
+
+// hostname returns the name of the host, or an empty string if it is unknown
+func hostname() string {
+	host, _ := os.Hostname()
+	return strings.Replace(host, string(filepath.Separator), "_", -1)
+}
+
+// isRunning returns true if the process with the provided pid is running
+func isRunning(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// Processes are only found on Windows if they are running, and cannot be signaled
+	if goruntime.GOOS == "windows" {
+		return true
+	}
+	// Processes of other users cannot be signaled, but are running
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// load reads the transaction staged in dir from its journal
+func load(fs afero.Fs, dir string) (*Transaction, error) {
+	t := &Transaction{
+		fs:      fs,
+		dir:     dir,
+		touched: make(map[string]string),
+	}
+
+	content, err := afero.ReadFile(fs, filepath.Join(dir, journalFile))
+	if os.IsNotExist(err) {
+		// Interrupted before touching any file
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		var e entry
+		// The last line may be incomplete if the transaction was interrupted while writing it,
+		// in which case the change it describes was not made
+		if line == "" || json.Unmarshal([]byte(line), &e) != nil {
+			continue
+		}
+		if e.Dir {
+			t.createdDirs = append(t.createdDirs, e.Path)
+			continue
+		}
+		t.touched[e.Path] = e.Backup
+		if n, err := strconv.Atoi(filepath.Base(e.Backup)); err == nil && n > t.staged {
+			t.staged = n
+		}
+	}
+
+	return t, nil
+}
+
+func (t *Transaction) nextStagingPath() string {
+	t.staged++
+	return filepath.Join(t.dir, strconv.Itoa(t.staged))
+}
+
+// journalFs is an afero.Fs that journals every change made to the files in a transaction
+type journalFs struct {
+	afero.Fs
+	tx *Transaction
+}
+
+// Name implements afero.Fs
+func (fs *journalFs) Name() string {
+	return "journalFs"
+}
+
+// Create implements afero.Fs
+func (fs *journalFs) Create(name string) (afero.File, error) {
+	return fs.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// OpenFile implements afero.Fs
+func (fs *journalFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&writeFlags == 0 {
+		return fs.Fs.OpenFile(name, flag, perm)
+	}
+
+	if err := fs.tx.backup(name); err != nil {
+		return nil, err
+	}
+	exists, err := afero.Exists(fs.Fs, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files that are not fully rewritten are modified in place
+	if exists && flag&os.O_TRUNC == 0 {
+		return fs.Fs.OpenFile(name, flag, perm)
+	}
+	if !exists && flag&os.O_CREATE == 0 {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	if _, err := fs.Fs.Stat(filepath.Dir(name)); err != nil {
+		return nil, err
+	}
+
+	// New contents are staged and renamed into place once complete
+	staged := fs.tx.nextStagingPath()
+	f, err := fs.Fs.OpenFile(staged, os.O_RDWR|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &stagedFile{File: f, fs: fs.Fs, name: name}, nil
+}
+
+// Mkdir implements afero.Fs
+func (fs *journalFs) Mkdir(name string, perm os.FileMode) error {
+	return fs.tx.mkdirAll(name, perm)
+}
+
+// MkdirAll implements afero.Fs
+func (fs *journalFs) MkdirAll(path string, perm os.FileMode) error {
+	return fs.tx.mkdirAll(path, perm)
+}
+
+// Remove implements afero.Fs
+func (fs *journalFs) Remove(name string) error {
+	if err := fs.tx.backup(name); err != nil {
+		return err
+	}
+	return fs.Fs.Remove(name)
+}
+
+// RemoveAll implements afero.Fs
+func (fs *journalFs) RemoveAll(path string) error {
+	if err := fs.tx.backup(path); err != nil {
+		return err
+	}
+	return fs.Fs.RemoveAll(path)
+}
+
+// Rename implements afero.Fs
+func (fs *journalFs) Rename(oldname, newname string) error {
+	if err := fs.tx.backup(oldname); err != nil {
+		return err
+	}
+	if err := fs.tx.backup(newname); err != nil {
+		return err
+	}
+	return fs.Fs.Rename(oldname, newname)
+}
+
+// Chmod implements afero.Fs
+func (fs *journalFs) Chmod(name string, mode os.FileMode) error {
+	if err := fs.tx.backup(name); err != nil {
+		return err
+	}
+	return fs.Fs.Chmod(name, mode)
+}
+
+// Chtimes implements afero.Fs
+func (fs *journalFs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	if err := fs.tx.backup(name); err != nil {
+		return err
+	}
+	return fs.Fs.Chtimes(name, atime, mtime)
+}
+
+// stagedFile is written in the staging directory and renamed into place when closed
+type stagedFile struct {
+	afero.File
+	fs   afero.Fs
+	name string
+}
+
+// Name implements afero.File
+func (f *stagedFile) Name() string {
+	return f.name
+}
+
+// Close implements afero.File
+func (f *stagedFile) Close() error {
+	staged := f.File.Name()
+	if err := f.File.Close(); err != nil {
+		return err
+	}
+	return f.fs.Rename(staged, f.name)
+}
+
+type transactionError struct {
+	op  string
+	err error
+}
+
+// Error implements error interface
+func (e transactionError) Error() string {
+	return fmt.Sprintf("failed to %s transaction: %v", e.op, e.err)
+}
+
+// Unwrap implements Wrapper interface
+func (e transactionError) Unwrap() error {
+	return e.err
+}
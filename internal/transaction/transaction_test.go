@@ -0,0 +1,193 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transaction
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/seamounts/kubeapi/pkg/model/file"
+)
+
+// stoppedPid is the pid of a process that is not running, higher than the maximum pid of the supported systems
+const stoppedPid = 1 << 30
+
+// newProject returns the file system of a project with a PROJECT file
+func newProject(t *testing.T) afero.Fs {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "PROJECT", []byte("version: \"1\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fs
+}
+
+// change updates the PROJECT file and creates apis/doc.go through tx
+func change(t *testing.T, tx *Transaction) {
+	t.Helper()
+	fs := tx.Fs()
+	if err := afero.WriteFile(fs, "PROJECT", []byte("version: \"2\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.MkdirAll("apis", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(fs, "apis/doc.go", []byte("package apis\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertFile fails t if the file at path does not have the provided contents, or exists if they are empty
+func assertFile(t *testing.T, fs afero.Fs, path, contents string) {
+	t.Helper()
+	actual, err := afero.ReadFile(fs, path)
+	switch {
+	case contents == "" && !os.IsNotExist(err):
+		t.Errorf("%s exists: %v", path, err)
+	case contents != "" && err != nil:
+		t.Errorf("unable to read %s: %v", path, err)
+	case contents != "" && string(actual) != contents:
+		t.Errorf("%s = %q, want %q", path, actual, contents)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	fs := newProject(t)
+	tx, err := Begin(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	change(t, tx)
+
+	changes, err := tx.Changes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []file.Change{{Path: "PROJECT", Action: file.Updated}, {Path: "apis/doc.go", Action: file.Created}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Changes() = %+v, want %+v", changes, expected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, fs, "PROJECT", "version: \"2\"\n")
+	assertFile(t, fs, "apis/doc.go", "package apis\n")
+	if exists, _ := afero.Exists(fs, stagingParent); exists {
+		t.Errorf("the staging directory %s was not removed", stagingParent)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	fs := newProject(t)
+	tx, err := Begin(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	change(t, tx)
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, fs, "PROJECT", "version: \"1\"\n")
+	assertFile(t, fs, "apis/doc.go", "")
+	for _, dir := range []string{"apis", stagingParent} {
+		if exists, _ := afero.Exists(fs, dir); exists {
+			t.Errorf("the directory %s was not removed", dir)
+		}
+	}
+}
+
+func TestRecover(t *testing.T) {
+	fs := newProject(t)
+
+	// A command of a process of this host crashed while changing the project
+	dir, err := afero.TempDir(fs, stagingParent, fmt.Sprintf("%s%s-%d-", stagingPrefix, hostname(), stoppedPid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	change(t, &Transaction{fs: fs, dir: dir, touched: make(map[string]string)})
+
+	// The transactions of running processes and of other hosts are not rolled back
+	running, err := Begin(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(running.Fs(), "main.go", []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	remote := fmt.Sprintf("%s/%sother-host-%d-1", stagingParent, stagingPrefix, stoppedPid)
+	if err := fs.MkdirAll(remote, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered, err := Recover(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovered != 1 {
+		t.Errorf("Recover() = %d, want 1", recovered)
+	}
+	assertFile(t, fs, "PROJECT", "version: \"1\"\n")
+	assertFile(t, fs, "apis/doc.go", "")
+	assertFile(t, fs, "main.go", "package main\n")
+	for _, d := range []string{running.dir, remote} {
+		if exists, _ := afero.DirExists(fs, d); !exists {
+			t.Errorf("the staging directory %s was removed", d)
+		}
+	}
+
+	if err := running.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsStale(t *testing.T) {
+	for name, test := range map[string]struct {
+		dir   string
+		stale bool
+	}{
+		"running process": {
+			dir: fmt.Sprintf("%s%s-%d-123", stagingPrefix, hostname(), os.Getpid()),
+		},
+		"stopped process": {
+			dir:   fmt.Sprintf("%s%s-%d-123", stagingPrefix, hostname(), stoppedPid),
+			stale: true,
+		},
+		"other host": {
+			dir: fmt.Sprintf("%sother-host-%d-123", stagingPrefix, stoppedPid),
+		},
+		"without owner": {
+			dir:   stagingPrefix + "123",
+			stale: true,
+		},
+		"invalid pid": {
+			dir:   fmt.Sprintf("%s%s-pid-123", stagingPrefix, hostname()),
+			stale: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if stale := isStale(test.dir); stale != test.stale {
+				t.Errorf("isStale(%q) = %t, want %t", test.dir, stale, test.stale)
+			}
+		})
+	}
+}
@@ -1,7 +1,8 @@
 package main
 
 import (
-	"log"
+	"fmt"
+	"os"
 
 	"github.com/seamounts/kubeapi/pkg/cli"
 	pluginv1 "github.com/seamounts/kubeapi/pkg/plugin/v1"
@@ -15,11 +16,14 @@ func main() {
 		cli.WithDefaultPlugin(
 			&pluginv1.Plugin{},
 		),
+		cli.WithExternalPlugins(),
 	)
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
+	// The errors of the commands are reported by the cli
 	if err := c.Run(); err != nil {
-		log.Fatal(err)
+		os.Exit(1)
 	}
 }
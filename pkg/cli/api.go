@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 
+	"github.com/seamounts/kubeapi/internal/chain"
 	"github.com/seamounts/kubeapi/internal/config"
 	"github.com/seamounts/kubeapi/pkg/plugin"
 	"github.com/spf13/cobra"
@@ -38,25 +39,59 @@ func (c cli) newAPIContext() plugin.Context {
 }
 
 func (c cli) bindCreateAPI(ctx plugin.Context, cmd *cobra.Command) {
-	getter, isGetter := c.resolvedPlugin.(plugin.CreateAPIPluginGetter)
-	if getter == nil || !isGetter {
-		err := fmt.Errorf("plugin does not support an API creation plugin")
+	ch, err := c.newChain(getCreateAPI, "API creation")
+	if err != nil {
 		cmdErr(cmd, err)
 		return
 	}
 
-	cfg, err := config.LoadInitialized()
+	cfg, err := config.LoadInitializedFromFs(c.runtime.FS)
 	if err != nil {
 		cmdErr(cmd, err)
 		return
 	}
 
-	createAPI := getter.GetCreateAPIPlugin()
-	createAPI.InjectConfig(&cfg.Config)
-	createAPI.BindFlags(cmd.Flags())
-	createAPI.UpdateContext(&ctx)
-	cmd.Long = ctx.Description
-	cmd.Example = ctx.Examples
-	cmd.RunE = runECmdFunc(cfg, createAPI,
+	ch.InjectPresets(chain.Presets(c.resolvedPlugins))
+	c.bindChain(ctx, cmd, cfg, ch,
 		fmt.Sprintf("failed to create API with version %q", c.projectVersion))
 }
+
+func (c *cli) newCreateAPIsCmd() *cobra.Command {
+	ctx := c.newAPIContext()
+	ctx.Description = `Scaffold Kubernetes APIs defined in a file.
+`
+	if !c.configured {
+		ctx.Description = fmt.Sprintf("%s\n%s", ctx.Description, runInProjectRootMsg)
+	}
+	cmd := &cobra.Command{
+		Use:     "apis",
+		Short:   "Scaffold Kubernetes APIs defined in a file",
+		Long:    ctx.Description,
+		Example: ctx.Examples,
+		RunE: errCmdFunc(
+			fmt.Errorf("apis subcommand requires an existing project"),
+		),
+	}
+
+	// Lookup the plugin for projectVersion and bind it to the command.
+	c.bindCreateAPIs(ctx, cmd)
+	return cmd
+}
+
+func (c cli) bindCreateAPIs(ctx plugin.Context, cmd *cobra.Command) {
+	ch, err := c.newChain(getCreateAPIs, "APIs creation")
+	if err != nil {
+		cmdErr(cmd, err)
+		return
+	}
+
+	cfg, err := config.LoadInitializedFromFs(c.runtime.FS)
+	if err != nil {
+		cmdErr(cmd, err)
+		return
+	}
+
+	ch.InjectPresets(chain.Presets(c.resolvedPlugins))
+	c.bindChain(ctx, cmd, cfg, ch,
+		fmt.Sprintf("failed to create APIs with version %q", c.projectVersion))
+}
@@ -2,23 +2,31 @@ package cli
 
 import (
 	"fmt"
-	"log"
+	"io"
+	"io/ioutil"
 	"os"
 
+	"github.com/seamounts/kubeapi/internal/chain"
 	internalconfig "github.com/seamounts/kubeapi/internal/config"
+	"github.com/seamounts/kubeapi/internal/prompt"
 	"github.com/seamounts/kubeapi/pkg/internal/validation"
 	"github.com/seamounts/kubeapi/pkg/plugin"
+	"github.com/seamounts/kubeapi/pkg/plugin/external"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
 const (
-	runInProjectRootMsg = `For project-specific information, run this command in the root directory of a
-project.
+	runInProjectRootMsg = `For project-specific information, run this command in a project directory or
+with --project-dir.
 `
 
 	projectVersionFlag = "project-version"
+	pluginsFlag        = "plugins"
 	helpFlag           = "help"
+	projectDirFlag     = "project-dir"
+	noInteractiveFlag  = "no-interactive"
 )
 
 // CLI interacts with a command line interface.
@@ -47,10 +55,40 @@ type cli struct {
 
 	defaultPlugin plugin.Base
 
-	// Plugins injected by options.
-	plugins map[string]plugin.Base
-
-	resolvedPlugin plugin.Base
+	// Plugins injected by options and discovered external plugins, in registration order.
+	plugins []plugin.Base
+	// Directories where external plugins are discovered, if enabled by options.
+	externalPluginDirs []string
+	// Executables of the external plugins found in those directories that are not loaded yet, which only
+	// happens when they are selected, listed or described.
+	externalPluginExecutables []external.Executable
+	// Why the executables found in those directories could not be used as plugins.
+	externalPluginErrs []error
+	// Keys of the plugins selected with --plugins.
+	pluginKeys []string
+
+	// Plugins that run the subcommands, in order.
+	resolvedPlugins []plugin.Base
+
+	// Environment the subcommands run in.
+	runtime plugin.Runtime
+	// Command line arguments, without the command name.
+	args []string
+	// Format of the output, set with --output.
+	output string
+	// Verbosity and format of the logs, set with --verbosity and --log-format.
+	verbosity int
+	logFormat string
+	// Root directory of the project, set with --project-dir.
+	projectDir string
+	// Whether the subcommands must not prompt users, set with --no-interactive.
+	noInteractive bool
+	// Whether the logger of the runtime was provided by options, instead of being set up with the flags.
+	customLogger bool
+	// Where the output is written, which is discarded by the runtime with --output json.
+	out io.Writer
+	// What the command did, written with --output json.
+	result *Result
 
 	// Base command.
 	cmd *cobra.Command
@@ -62,7 +100,6 @@ func New(opts ...Option) (CLI, error) {
 	cli := &cli{
 		commandName:           "kubeapi",
 		defaultProjectVersion: internalconfig.DefaultVersion,
-		plugins:               make(map[string]plugin.Base),
 	}
 
 	for _, opt := range opts {
@@ -71,14 +108,27 @@ func New(opts ...Option) (CLI, error) {
 		}
 	}
 
-	cli.initialize()
+	if cli.args == nil {
+		cli.args = os.Args[1:]
+	}
+	cli.customLogger = cli.runtime.Logger != nil
+
+	if err := cli.initialize(); err != nil {
+		return nil, err
+	}
 
 	return cli, nil
 }
 
-// Run runs the cli.
+// Run runs the cli. With --output json, the result of the command is written even if it failed.
 func (c cli) Run() error {
-	return c.cmd.Execute()
+	cmd, err := c.cmd.ExecuteC()
+	if c.jsonOutputEnabled() {
+		if writeErr := c.writeResult(c.out, cmd, err); writeErr != nil && err == nil {
+			return writeErr
+		}
+	}
+	return err
 }
 
 // WithCommandName is an Option that sets the cli's root command name.
@@ -101,25 +151,20 @@ func WithDefaultProjectVersion(version string) Option {
 	}
 }
 
-// WithPlugins is an Option that sets the cli's plugins.
+// WithPlugins is an Option that sets the cli's plugins. Several plugins may support the same project
+// version, in which case they are selected with the --plugins flag or the layout of the project.
 func WithPlugins(plugins ...plugin.Base) Option {
 	return func(c *cli) error {
-		for _, p := range plugins {
-			for _, version := range p.SupportedProjectVersions() {
-				c.plugins[version] = p
-			}
-		}
-		for _, p := range c.plugins {
-			if err := validatePlugins(p); err != nil {
-				return fmt.Errorf("broken pre-set plugins: %v", err)
-			}
+		c.plugins = append(c.plugins, plugins...)
+		if err := validatePlugins(c.plugins...); err != nil {
+			return fmt.Errorf("broken pre-set plugins: %v", err)
 		}
 		return nil
 	}
 }
 
-// WithDefaultPlugins is an Option that sets the cli's default plugins. Only
-// one plugin per project version is allowed.
+// WithDefaultPlugin is an Option that sets the plugin that runs the subcommands of projects
+// without a layout when the --plugins flag is not set.
 func WithDefaultPlugin(p plugin.Base) Option {
 	return func(c *cli) error {
 		if err := validatePlugin(p); err != nil {
@@ -131,6 +176,39 @@ func WithDefaultPlugin(p plugin.Base) Option {
 	}
 }
 
+// WithExternalPlugins is an Option that makes the cli discover external plugins, which are the
+// executables named <command name>-<plugin name> found in dirs, or in external.DefaultDirs() if
+// none is provided. External plugins are selected with the --plugins flag, and only run to load them when
+// they are selected, listed or described.
+func WithExternalPlugins(dirs ...string) Option {
+	return func(c *cli) error {
+		if len(dirs) == 0 {
+			dirs = external.DefaultDirs()
+		}
+		c.externalPluginDirs = dirs
+		return nil
+	}
+}
+
+// WithRuntime is an Option that sets the environment the subcommands run in, e.g. the file system of the
+// project and the writers of their output. Unset fields default to the ones of the project in the working
+// directory of the process.
+func WithRuntime(rt plugin.Runtime) Option {
+	return func(c *cli) error {
+		c.runtime = rt
+		return nil
+	}
+}
+
+// WithArgs is an Option that sets the command line arguments the cli runs with, without the command
+// name, instead of the ones of the process.
+func WithArgs(args ...string) Option {
+	return func(c *cli) error {
+		c.args = append([]string{}, args...)
+		return nil
+	}
+}
+
 // WithExtraCommands is an Option that adds extra subcommands to the cli.
 // Adding extra commands that duplicate existing commands results in an error.
 func WithExtraCommands(cmds ...*cobra.Command) Option {
@@ -148,9 +226,13 @@ func (c *cli) initialize() error {
 		return err
 	}
 
+	if err := c.setupRuntime(); err != nil {
+		return err
+	}
+
 	// Configure the project version first for plugin retrieval in command
 	// constructors.
-	projectConfig, err := internalconfig.Read()
+	projectConfig, err := internalconfig.ReadFromFs(c.runtime.FS, internalconfig.DefaultPath)
 	if os.IsNotExist(err) {
 		c.configured = false
 		if c.projectVersion == "" {
@@ -169,12 +251,37 @@ func (c *cli) initialize() error {
 		return err
 	}
 
-	c.resolvedPlugin = c.plugins[c.projectVersion]
-	if c.resolvedPlugin == nil {
-		c.resolvedPlugin = c.defaultPlugin
+	logger, err := setupLogging(c.runtime.Stderr, c.verbosity, c.logFormat)
+	if err != nil {
+		return fmt.Errorf("failed to set up logging: %v", err)
+	}
+	if !c.customLogger {
+		c.runtime.Logger = logger
+	}
+
+	// Messages for humans are discarded when the result is written as JSON.
+	if c.jsonOutputEnabled() {
+		c.runtime.Stdout = ioutil.Discard
+	}
+
+	if len(c.externalPluginDirs) != 0 {
+		c.discoverExternalPlugins()
+	}
+
+	// Plugins are selected with --plugins, or with the layout of the project.
+	keys := c.pluginKeys
+	if len(keys) == 0 && projectConfig != nil {
+		keys = projectConfig.Layout
+	}
+	c.loadSelectedExternalPlugins(keys...)
+	if c.resolvedPlugins, err = c.resolvePlugins(keys); err != nil {
+		return err
 	}
 
 	c.cmd = c.buildRootCmd()
+	c.cmd.SetOut(c.out)
+	c.cmd.SetErr(c.runtime.Stderr)
+	c.cmd.SetArgs(c.args)
 	// Add extra commands injected by options.
 	for _, cmd := range c.extraCommands {
 		for _, subCmd := range c.cmd.Commands() {
@@ -188,21 +295,58 @@ func (c *cli) initialize() error {
 	return nil
 }
 
+// setupRuntime sets the unset fields of the runtime, rooting it at the project directory, which is set
+// with --project-dir or else found from the working directory.
+func (c *cli) setupRuntime() error {
+	dir := c.projectDir
+	if c.runtime.ProjectRoot == "" && dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("unable to get the working directory: %v", err)
+		}
+		if dir, err = internalconfig.FindRoot(afero.NewOsFs(), wd); err != nil {
+			return fmt.Errorf("unable to find the project root: %v", err)
+		}
+	}
+
+	rt, err := c.runtime.WithDefaults(dir)
+	if err != nil {
+		return err
+	}
+	// Users are only prompted on terminals, and the prompts would corrupt the JSON output
+	rt.Interactive = rt.Interactive || prompt.IsTerminal(rt.Stdin) && prompt.IsTerminal(rt.Stdout)
+	if c.noInteractive || c.jsonOutputEnabled() {
+		rt.Interactive = false
+	}
+	c.out = rt.Stdout
+	c.result = &Result{}
+	c.runtime = c.recordIn(rt)
+	return nil
+}
+
 // parseBaseFlags parses the command line arguments, looking for flags that
 // affect initialization of a cli. An error is returned only if an error
 // unrelated to flag parsing occurs.
 func (c *cli) parseBaseFlags() error {
 	// Create a dummy "base" flagset to populate from CLI args.
-	fs := pflag.NewFlagSet("base", pflag.ExitOnError)
+	fs := pflag.NewFlagSet("base", pflag.ContinueOnError)
 	fs.ParseErrorsWhitelist = pflag.ParseErrorsWhitelist{UnknownFlags: true}
+	// Invalid values are reported by the command, which parses the same flags.
+	fs.SetOutput(ioutil.Discard)
 
 	var help bool
 	// Set base flags that require pre-parsing to initialize c.
 	fs.BoolVarP(&help, helpFlag, "h", false, "print help")
 	fs.StringVar(&c.projectVersion, projectVersionFlag, c.defaultProjectVersion, "project version")
+	fs.StringSliceVar(&c.pluginKeys, pluginsFlag, nil, "plugins")
+	fs.StringVar(&c.output, outputFlag, textOutput, "output format")
+	fs.IntVarP(&c.verbosity, verbosityFlag, "v", 0, "log verbosity")
+	fs.StringVar(&c.logFormat, logFormatFlag, textLogFormat, "log format")
+	fs.StringVar(&c.projectDir, projectDirFlag, "", "project directory")
+	fs.BoolVar(&c.noInteractive, noInteractiveFlag, false, "disable prompts")
 
 	// Parse current CLI args outside of cobra.
-	err := fs.Parse(os.Args[1:])
+	err := fs.Parse(c.args)
 	// User needs *generic* help if args are incorrect or --help is set and
 	// --project-version is not set. Plugin-specific help is given if a
 	// plugin.Context is updated, which does not require this field.
@@ -211,22 +355,142 @@ func (c *cli) parseBaseFlags() error {
 	return nil
 }
 
+// discoverExternalPlugins finds the executables of the external plugins, without loading them.
+func (c *cli) discoverExternalPlugins() {
+	c.externalPluginExecutables = external.Find(c.commandName+"-", c.externalPluginDirs...)
+}
+
+// loadSelectedExternalPlugins loads the external plugins that may have one of the provided keys, names or
+// short names, which are the executables named after the key, the name or the short name.
+func (c *cli) loadSelectedExternalPlugins(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	names := make(map[string]struct{}, 3*len(keys))
+	for _, key := range keys {
+		name, _ := plugin.SplitKey(key)
+		names[key] = struct{}{}
+		names[name] = struct{}{}
+		names[plugin.GetShortName(name)] = struct{}{}
+	}
+	c.loadExternalPlugins(func(executable external.Executable) bool {
+		_, found := names[executable.Name]
+		return found
+	})
+}
+
+// loadExternalPlugins makes the external plugins whose executables match available, all of them if match is
+// nil, logging the executables that could not be loaded as plugins.
+func (c *cli) loadExternalPlugins(match func(external.Executable) bool) {
+	var pending []external.Executable
+	for _, executable := range c.externalPluginExecutables {
+		if match != nil && !match(executable) {
+			pending = append(pending, executable)
+			continue
+		}
+
+		p, err := external.Load(executable.Path, c.runtime.Logger)
+		if err != nil {
+			c.runtime.Logger.V(1).Info("ignoring an executable that is not an external plugin", "error", err.Error())
+			c.externalPluginErrs = append(c.externalPluginErrs, err)
+			continue
+		}
+		if err := validatePlugins(append(c.plugins, p)...); err != nil {
+			c.runtime.Logger.Info("ignoring an invalid external plugin", "path", p.Path(), "error", err.Error())
+			c.externalPluginErrs = append(c.externalPluginErrs, fmt.Errorf("external plugin %s: %v", p.Path(), err))
+			continue
+		}
+		c.plugins = append(c.plugins, p)
+	}
+	c.externalPluginExecutables = pending
+}
+
+// resolvePlugins returns the plugins with the provided keys, in order. Without keys, the default
+// plugin is returned if it supports the project version, or else the first plugin that does.
+func (c cli) resolvePlugins(keys []string) ([]plugin.Base, error) {
+	if len(keys) == 0 {
+		if c.defaultPlugin != nil && supportsProjectVersion(c.defaultPlugin, c.projectVersion) {
+			return []plugin.Base{c.defaultPlugin}, nil
+		}
+		for _, p := range c.plugins {
+			if supportsProjectVersion(p, c.projectVersion) {
+				return []plugin.Base{p}, nil
+			}
+		}
+		return nil, fmt.Errorf("no plugins for project version %q", c.projectVersion)
+	}
+
+	resolved := make([]plugin.Base, 0, len(keys))
+	seen := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		p, err := c.findPlugin(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, found := seen[plugin.KeyFor(p)]; found {
+			return nil, fmt.Errorf("plugin %q is selected more than once", plugin.KeyFor(p))
+		}
+		seen[plugin.KeyFor(p)] = struct{}{}
+		resolved = append(resolved, p)
+	}
+	return resolved, nil
+}
+
+// findPlugin returns the plugin with the provided key, name or short name that supports the project version.
+func (c cli) findPlugin(key string) (plugin.Base, error) {
+	p, err := c.lookupPlugin(key)
+	if err != nil {
+		return nil, err
+	}
+	if !supportsProjectVersion(p, c.projectVersion) {
+		return nil, fmt.Errorf("plugin %q does not support project version %q", plugin.KeyFor(p), c.projectVersion)
+	}
+	return p, nil
+}
+
+// lookupPlugin returns the first plugin with the provided key, name or short name.
+func (c cli) lookupPlugin(key string) (plugin.Base, error) {
+	if p := chain.Lookup(c.plugins, key); p != nil {
+		return p, nil
+	}
+	return nil, fmt.Errorf("plugin %q not found", key)
+}
+
+// supportsProjectVersion returns true if p supports the project version.
+func supportsProjectVersion(p plugin.Base, projectVersion string) bool {
+	for _, version := range p.SupportedProjectVersions() {
+		if version == projectVersion {
+			return true
+		}
+	}
+	return false
+}
+
 // validate validates fields in a cli.
 func (c cli) validate() error {
+	if c.output != textOutput && c.output != jsonOutput {
+		return fmt.Errorf("invalid output format %q: must be %q or %q", c.output, textOutput, jsonOutput)
+	}
+	if c.logFormat != textLogFormat && c.logFormat != jsonLogFormat {
+		return fmt.Errorf("invalid log format %q: must be %q or %q", c.logFormat, textLogFormat, jsonLogFormat)
+	}
+
 	// Validate project version.
 	if err := validation.ValidateProjectVersion(c.projectVersion); err != nil {
 		return fmt.Errorf("invalid project version %q: %v", c.projectVersion, err)
 	}
 
-	if _, versionFound := c.plugins[c.projectVersion]; !versionFound {
+	versionFound := false
+	for _, p := range c.plugins {
+		versionFound = versionFound || supportsProjectVersion(p, c.projectVersion)
+	}
+	if !versionFound {
 		return fmt.Errorf("no plugins for project version %q", c.projectVersion)
 	}
 
 	// Validate plugin versions and name.
-	for _, versionedPlugin := range c.plugins {
-		if err := validatePlugins(versionedPlugin); err != nil {
-			return err
-		}
+	if err := validatePlugins(c.plugins...); err != nil {
+		return err
 	}
 
 	return nil
@@ -274,10 +538,35 @@ func validatePlugin(p plugin.Base) error {
 func (c cli) buildRootCmd() *cobra.Command {
 	rootCmd := c.defaultCommand()
 
+	// Register --plugins on every command so that it does not cause a parse error.
+	rootCmd.PersistentFlags().StringSlice(pluginsFlag, nil,
+		"comma-separated keys, names or short names of the plugins to run, in order, instead of "+
+			"the ones of the project layout or the default one")
+	rootCmd.PersistentFlags().String(outputFlag, textOutput,
+		fmt.Sprintf("format of the output: %q for humans or %q for a single machine-readable result "+
+			"of the command", textOutput, jsonOutput))
+	rootCmd.PersistentFlags().IntP(verbosityFlag, "v", 0,
+		"verbosity of the logs written to the standard error, including the ones of the code generators")
+	rootCmd.PersistentFlags().String(logFormatFlag, textLogFormat,
+		fmt.Sprintf("format of the logs: %q or %q for a JSON object per line", textLogFormat, jsonLogFormat))
+	rootCmd.PersistentFlags().String(projectDirFlag, "",
+		"root directory of the project, defaults to the closest directory to the working directory, itself "+
+			"included, that contains a PROJECT file, or else to the working directory")
+	rootCmd.PersistentFlags().Bool(noInteractiveFlag, false,
+		"never prompt for the information missing from the flags, which is otherwise asked for when the "+
+			"standard input and output are terminals")
+	rootCmd.SetFlagErrorFunc(flagError)
+	if c.jsonOutputEnabled() {
+		// Errors are part of the result
+		rootCmd.SilenceErrors = true
+		rootCmd.SilenceUsage = true
+	}
+
 	// kubebuilder create
 	createCmd := c.newCreateCmd()
 	// kubebuilder create api
 	createCmd.AddCommand(c.newCreateAPICmd())
+	createCmd.AddCommand(c.newCreateAPIsCmd())
 	if createCmd.HasSubCommands() {
 		rootCmd.AddCommand(createCmd)
 	}
@@ -285,6 +574,40 @@ func (c cli) buildRootCmd() *cobra.Command {
 	// kubebuilder init
 	rootCmd.AddCommand(c.newInitCmd())
 
+	// kubeapi refactor
+	refactorCmd := c.newRefactorCmd()
+	// kubeapi refactor rename-kind
+	refactorCmd.AddCommand(c.newRenameKindCmd())
+	// kubeapi refactor move-kind
+	refactorCmd.AddCommand(c.newMoveKindCmd())
+	rootCmd.AddCommand(refactorCmd)
+
+	// kubeapi import
+	importCmd := c.newImportCmd()
+	// kubeapi import crd
+	importCmd.AddCommand(c.newImportCRDCmd())
+	rootCmd.AddCommand(importCmd)
+
+	// kubeapi generate
+	generateCmd := c.newGenerateCmd()
+	// kubeapi generate client
+	generateCmd.AddCommand(c.newGenerateClientCmd())
+	rootCmd.AddCommand(generateCmd)
+
+	// kubeapi templates
+	templatesCmd := c.newTemplatesCmd()
+	// kubeapi templates export
+	templatesCmd.AddCommand(c.newExportTemplatesCmd())
+	rootCmd.AddCommand(templatesCmd)
+
+	// kubeapi plugins
+	pluginsCmd := c.newPluginsCmd()
+	// kubeapi plugins list
+	pluginsCmd.AddCommand(c.newListPluginsCmd())
+	// kubeapi plugins describe
+	pluginsCmd.AddCommand(c.newDescribePluginCmd())
+	rootCmd.AddCommand(pluginsCmd)
+
 	return rootCmd
 }
 
@@ -322,10 +645,8 @@ After the scaffold is written, api will run make on the project.
 `,
 			c.commandName, c.commandName),
 
-		Run: func(cmd *cobra.Command, args []string) {
-			if err := cmd.Help(); err != nil {
-				log.Fatal(err)
-			}
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
 		},
 	}
 }
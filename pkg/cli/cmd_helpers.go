@@ -21,10 +21,33 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/seamounts/kubeapi/internal/chain"
+	"github.com/seamounts/kubeapi/internal/cmdutil"
 	"github.com/seamounts/kubeapi/internal/config"
 	"github.com/seamounts/kubeapi/pkg/plugin"
 )
 
+// newChain returns the chain of the subcommands of the resolved plugins, skipping the plugins that
+// do not implement it. An error is returned if none of them does.
+func (c cli) newChain(get subcommandGetter, what string) (*chain.Chain, error) {
+	return chain.New(c.resolvedPlugins, get, what)
+}
+
+// bindChain binds the chain of subcommands to cmd, making it run them and save cfg.
+func (c cli) bindChain(ctx plugin.Context, cmd *cobra.Command, cfg *config.Config, ch *chain.Chain,
+	msg string) {
+	ch.InjectConfig(&cfg.Config)
+	ch.BindFlags(cmd.Flags())
+	if err := ch.Err(); err != nil {
+		cmdErr(cmd, err)
+		return
+	}
+	ch.UpdateContext(&ctx)
+	cmd.Long = ctx.Description
+	cmd.Example = ctx.Examples
+	cmd.RunE = c.runECmdFunc(cfg, ch, msg)
+}
+
 // cmdErr updates a cobra command to output error information when executed
 // or used with the help flag.
 func cmdErr(cmd *cobra.Command, err error) {
@@ -46,15 +69,22 @@ func errCmdFunc(err error) func(*cobra.Command, []string) error {
 }
 
 // runECmdFunc returns a cobra RunE function that runs gsub and saves the
-// config, which may have been modified by gsub.
-func runECmdFunc(
-	c *config.Config,
+// config, which may have been modified by gsub, unless it was a dry run.
+func (c cli) runECmdFunc(
+	cfg *config.Config,
 	gsub plugin.GenericSubcommand, // nolint:interfacer
 	msg string) func(*cobra.Command, []string) error {
-	return func(*cobra.Command, []string) error {
-		if err := gsub.Run(); err != nil {
-			return fmt.Errorf("%s: %v", msg, err)
+	return func(cmd *cobra.Command, _ []string) error {
+		if ch, isChain := gsub.(*chain.Chain); isChain {
+			c.result.Plugins = ch.Keys()
+		}
+		changes, err := cmdutil.RunInTransaction(cmd.Context(), c.runtime, cfg, gsub, msg)
+		if err != nil && changes == nil {
+			return err
 		}
-		return c.Save()
+
+		// Merge conflicts report the committed changes along with the error
+		c.result.setFiles(changes)
+		return err
 	}
 }
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/seamounts/kubeapi/internal/config"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+)
+
+func (c *cli) newGenerateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "generate",
+		Short: "Generate code for Kubernetes APIs",
+		Long:  `Generate code for Kubernetes APIs, e.g. the clients of API types of other modules.`,
+	}
+}
+
+func (c *cli) newGenerateClientCmd() *cobra.Command {
+	ctx := plugin.Context{
+		CommandName: c.commandName,
+		Description: `Generate the clients of API types of other modules.
+`,
+	}
+	if !c.configured {
+		ctx.Description = fmt.Sprintf("%s\n%s", ctx.Description, runInProjectRootMsg)
+	}
+
+	cmd := &cobra.Command{
+		Use:     "client",
+		Short:   "Generate the clients of API types of other modules",
+		Long:    ctx.Description,
+		Example: ctx.Examples,
+		RunE: errCmdFunc(
+			fmt.Errorf("client subcommand requires an existing project"),
+		),
+	}
+
+	// Lookup the plugin for projectVersion and bind it to the command.
+	c.bindGenerateClient(ctx, cmd)
+	return cmd
+}
+
+func (c cli) bindGenerateClient(ctx plugin.Context, cmd *cobra.Command) {
+	ch, err := c.newChain(getGenerateClient, "client generation")
+	if err != nil {
+		cmdErr(cmd, err)
+		return
+	}
+
+	cfg, err := config.LoadInitializedFromFs(c.runtime.FS)
+	if err != nil {
+		cmdErr(cmd, err)
+		return
+	}
+
+	c.bindChain(ctx, cmd, cfg, ch,
+		fmt.Sprintf("failed to generate clients with version %q", c.projectVersion))
+}
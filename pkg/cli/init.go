@@ -2,7 +2,6 @@ package cli
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"strconv"
 	"strings"
@@ -63,8 +62,10 @@ func (c cli) getInitHelpExamples() string {
 
 func (c cli) getAvailableProjectVersions() (projectVersions []string) {
 	versionSet := make(map[string]struct{})
-	for version, _ := range c.plugins {
-		versionSet[version] = struct{}{}
+	for _, p := range c.plugins {
+		for _, version := range p.SupportedProjectVersions() {
+			versionSet[version] = struct{}{}
+		}
 	}
 	for version := range versionSet {
 		projectVersions = append(projectVersions, strconv.Quote(version))
@@ -73,32 +74,35 @@ func (c cli) getAvailableProjectVersions() (projectVersions []string) {
 }
 
 func (c cli) bindInit(ctx plugin.Context, cmd *cobra.Command) {
-	getter, isGetter := c.resolvedPlugin.(plugin.InitPluginGetter)
-	if getter == nil || !isGetter {
-		err := fmt.Errorf("plugin does not support an Init plugin")
+	ch, err := c.newChain(getInit, "project initialization")
+	if err != nil {
 		cmdErr(cmd, err)
 		return
 	}
 
 	cfg := internalconfig.New(internalconfig.DefaultPath)
+	cfg.InjectFS(c.runtime.FS)
 	cfg.Version = c.projectVersion
+	// Later commands run the plugins selected to initialize the project
+	if len(c.pluginKeys) != 0 {
+		for _, p := range c.resolvedPlugins {
+			cfg.Layout = append(cfg.Layout, plugin.KeyFor(p))
+		}
+	}
 
-	init := getter.GetInitPlugin()
-	init.InjectConfig(&cfg.Config)
-	init.BindFlags(cmd.Flags())
-	init.UpdateContext(&ctx)
-	cmd.Long = ctx.Description
-	cmd.Example = ctx.Examples
-	cmd.RunE = func(*cobra.Command, []string) error {
+	c.bindChain(ctx, cmd, cfg, ch,
+		fmt.Sprintf("failed to initialize project with version %q", c.projectVersion))
+	if ch.Err() != nil {
+		return
+	}
+	runE := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		// Check if a config is initialized in the command runner so the check
 		// doesn't erroneously fail other commands used in initialized projects.
-		_, err := internalconfig.Read()
+		_, err := internalconfig.ReadFromFs(c.runtime.FS, internalconfig.DefaultPath)
 		if err == nil || os.IsExist(err) {
-			log.Fatal("config already initialized")
-		}
-		if err := init.Run(); err != nil {
-			return fmt.Errorf("failed to initialize project with version %q: %v", c.projectVersion, err)
+			return internalconfig.AlreadyInitializedError()
 		}
-		return cfg.Save()
+		return runE(cmd, args)
 	}
 }
@@ -0,0 +1,209 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	klogv1 "k8s.io/klog"
+	"k8s.io/klog/v2"
+	"k8s.io/klog/v2/klogr"
+)
+
+const (
+	verbosityFlag = "verbosity"
+	logFormatFlag = "log-format"
+
+	// textLogFormat writes the log lines as klog formats them, the default
+	textLogFormat = "text"
+	// jsonLogFormat writes a JSON object per log line
+	jsonLogFormat = "json"
+)
+
+// setupLogging makes both klog versions, the one of the plugins and the one of the code generators,
+// write to w the lines of the provided verbosity or lower, in the provided format. It returns the logger
+// of the subcommands, which writes to w too.
+func setupLogging(w io.Writer, verbosity int, format string) (logr.Logger, error) {
+	logger := klogr.New()
+	if format == jsonLogFormat {
+		jsonWriter := &jsonLogWriter{w: w}
+		w = jsonWriter
+		logger = jsonLogger{w: jsonWriter, verbosity: verbosity}
+	}
+
+	settings := map[string]string{
+		"logtostderr":     "false",
+		"alsologtostderr": "false",
+		// No line is also written to the standard error of the process: the threshold is above the
+		// highest severity, FATAL
+		"stderrthreshold": "4",
+		"v":               strconv.Itoa(verbosity),
+	}
+	for _, initFlags := range []func(*flag.FlagSet){klogv1.InitFlags, klog.InitFlags} {
+		fs := flag.NewFlagSet("klog", flag.ContinueOnError)
+		initFlags(fs)
+		for name, value := range settings {
+			if err := fs.Set(name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// klog writes the lines of a severity to the outputs of the lower severities too,
+	// so only the output of the lowest one is kept to write each line once
+	klogv1.SetOutputBySeverity("INFO", w)
+	klog.SetOutputBySeverity("INFO", w)
+	for _, severity := range []string{"WARNING", "ERROR", "FATAL"} {
+		klogv1.SetOutputBySeverity(severity, ioutil.Discard)
+		klog.SetOutputBySeverity(severity, ioutil.Discard)
+	}
+	return logger, nil
+}
+
+// klogLevels are the levels of the klog severities, by the first letter of their lines
+var klogLevels = map[byte]string{
+	'I': "info",
+	'W': "warning",
+	'E': "error",
+	'F': "fatal",
+}
+
+// jsonLogWriter writes the klog lines written to it as JSON objects, e.g.
+//
+//	{"caller":"codegen.go:125","level":"info","msg":"Generating deepcopy funcs","ts":"2020-10-19T03:06:13.123456Z"}
+type jsonLogWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Write implements io.Writer. klog writes a line per call, made of a header and of the message:
+//
+//	Lmmdd hh:mm:ss.uuuuuu threadid file:line] msg
+func (w *jsonLogWriter) Write(data []byte) (int, error) {
+	msg := strings.TrimSuffix(string(data), "\n")
+	level, caller := klogLevels['I'], ""
+	if end := strings.Index(msg, "] "); end != -1 {
+		header := strings.Fields(msg[:end])
+		if l, found := klogLevels[msg[0]]; found && len(header) == 4 {
+			level, caller, msg = l, header[3], msg[end+2:]
+		}
+	}
+
+	if err := w.writeLine(level, caller, msg, nil); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// writeLine writes a JSON object with the provided fields and keysAndValues
+func (w *jsonLogWriter) writeLine(level, caller, msg string, keysAndValues []interface{}) error {
+	line := make(map[string]interface{}, 4+len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		line[fmt.Sprint(keysAndValues[i])] = keysAndValues[i+1]
+	}
+	line["level"] = level
+	line["ts"] = time.Now().UTC().Format(time.RFC3339Nano)
+	if caller != "" {
+		line["caller"] = caller
+	}
+	line["msg"] = msg
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err = w.w.Write(append(data, '\n'))
+	return err
+}
+
+var _ logr.Logger = jsonLogger{}
+
+// jsonLogger is the logr.Logger of the subcommands with --log-format json, which writes their
+// key-value pairs as fields of the JSON objects instead of formatting them into the messages
+type jsonLogger struct {
+	w         *jsonLogWriter
+	verbosity int
+	level     int
+	name      string
+	values    []interface{}
+}
+
+// Enabled implements logr.Logger
+func (l jsonLogger) Enabled() bool {
+	return l.level <= l.verbosity
+}
+
+// Info implements logr.Logger
+func (l jsonLogger) Info(msg string, keysAndValues ...interface{}) {
+	if l.Enabled() {
+		l.write(klogLevels['I'], msg, keysAndValues)
+	}
+}
+
+// Error implements logr.Logger
+func (l jsonLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	l.write(klogLevels['E'], msg, append([]interface{}{"error", fmt.Sprint(err)}, keysAndValues...))
+}
+
+// V implements logr.Logger
+func (l jsonLogger) V(level int) logr.Logger {
+	l.level += level
+	return l
+}
+
+// WithValues implements logr.Logger
+func (l jsonLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	l.values = append(append([]interface{}{}, l.values...), keysAndValues...)
+	return l
+}
+
+// WithName implements logr.Logger
+func (l jsonLogger) WithName(name string) logr.Logger {
+	if l.name != "" {
+		name = l.name + "/" + name
+	}
+	l.name = name
+	return l
+}
+
+func (l jsonLogger) write(level, msg string, keysAndValues []interface{}) {
+	caller := ""
+	// Skip write and the method of logr.Logger that called it
+	if _, file, line, ok := runtime.Caller(2); ok {
+		caller = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+	values := append(append([]interface{}{}, l.values...), keysAndValues...)
+	if l.name != "" {
+		values = append(values, "logger", l.name)
+	}
+	// Logging errors are ignored, as klog does
+	_ = l.w.writeLine(level, caller, msg, values)
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	internalconfig "github.com/seamounts/kubeapi/internal/config"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+	"github.com/seamounts/kubeapi/pkg/scaffold"
+)
+
+const (
+	outputFlag = "output"
+
+	// textOutput writes messages for humans, the default
+	textOutput = "text"
+	// jsonOutput writes a single Result as JSON once the command ran, and no message for humans
+	jsonOutput = "json"
+)
+
+// Codes of the errors of a Result
+const (
+	// ErrorCodeFailed is the code of the errors that have no more specific code
+	ErrorCodeFailed = "Failed"
+	// ErrorCodeInvalidArguments is the code of the errors caused by invalid flags or arguments
+	ErrorCodeInvalidArguments = "InvalidArguments"
+	// ErrorCodeNotInitialized is the code of the errors caused by running a command in a project that was not initialized
+	ErrorCodeNotInitialized = "NotInitialized"
+	// ErrorCodeAlreadyInitialized is the code of the errors caused by initializing a project twice
+	ErrorCodeAlreadyInitialized = "AlreadyInitialized"
+	// ErrorCodeFileAlreadyExists is the code of the errors caused by scaffolding a file that already exists
+	ErrorCodeFileAlreadyExists = "FileAlreadyExists"
+	// ErrorCodeMergeConflict is the code of the errors caused by conflicting changes to a scaffolded file
+	ErrorCodeMergeConflict = "MergeConflict"
+)
+
+// Result is what a command writes with --output json
+type Result struct {
+	// Command is the path of the command that ran, e.g. "kubeapi create api"
+	Command string `json:"command"`
+	// Plugins are the keys of the plugins that ran the subcommand, in order
+	Plugins []string `json:"plugins,omitempty"`
+	// Resources are the resources that were created, renamed or moved, with their new GVK
+	Resources []config.GVK `json:"resources,omitempty"`
+	// Files are the project files that were created, updated, deleted or left untouched, sorted by path.
+	// They are the files that would be changed for dry runs.
+	Files []file.Change `json:"files,omitempty"`
+	// Generators are the code generators that ran, in order
+	Generators []Generator `json:"generators,omitempty"`
+	// AvailablePlugins are the plugins listed or described by the plugins commands
+	AvailablePlugins []PluginInfo `json:"availablePlugins,omitempty"`
+	// Error is why the command failed, if it did
+	Error *Error `json:"error,omitempty"`
+
+	// recorded are the changes made to scaffolded files, including the skipped ones
+	recorded []file.Change
+}
+
+// Generator is a code generator that ran
+type Generator struct {
+	// Name is the name of the generator, e.g. "deepcopy"
+	Name string `json:"name"`
+	// DurationMillis is how long the generator ran, in milliseconds
+	DurationMillis int64 `json:"durationMillis"`
+}
+
+// PluginInfo describes an available plugin
+type PluginInfo struct {
+	// Key is the key of the plugin
+	Key string `json:"key"`
+	// ProjectVersions are the project versions the plugin supports
+	ProjectVersions []string `json:"projectVersions"`
+	// Subcommands are the subcommands the plugin implements
+	Subcommands []string `json:"subcommands,omitempty"`
+	// Source is where the plugin comes from
+	Source string `json:"source"`
+	// Selected is true if the plugin runs the subcommands of the current project
+	Selected bool `json:"selected,omitempty"`
+}
+
+// Error is why a command failed
+type Error struct {
+	// Code identifies the kind of the error, e.g. ErrorCodeNotInitialized
+	Code string `json:"code"`
+	// Message is the message of the error
+	Message string `json:"message"`
+}
+
+// newError returns the Error of err with the code of its kind
+func newError(err error) *Error {
+	code := ErrorCodeFailed
+	switch {
+	case isInvalidArgumentsError(err):
+		code = ErrorCodeInvalidArguments
+	case internalconfig.IsNotInitializedError(err):
+		code = ErrorCodeNotInitialized
+	case internalconfig.IsAlreadyInitializedError(err):
+		code = ErrorCodeAlreadyInitialized
+	case scaffold.IsFileAlreadyExistsError(err):
+		code = ErrorCodeFileAlreadyExists
+	case scaffold.IsMergeConflictError(err):
+		code = ErrorCodeMergeConflict
+	}
+	return &Error{Code: code, Message: err.Error()}
+}
+
+// invalidArgumentsError is returned if the flags or arguments of a command are invalid
+type invalidArgumentsError struct {
+	error
+}
+
+// Unwrap implements Wrapper interface
+func (e invalidArgumentsError) Unwrap() error {
+	return e.error
+}
+
+func isInvalidArgumentsError(err error) bool {
+	return errors.As(err, &invalidArgumentsError{})
+}
+
+// flagError is the flag error function of every command, which marks the errors as invalid arguments
+func flagError(_ *cobra.Command, err error) error {
+	return invalidArgumentsError{err}
+}
+
+// jsonOutputEnabled returns true if the command writes its Result as JSON
+func (c cli) jsonOutputEnabled() bool {
+	return c.output == jsonOutput
+}
+
+// recordIn makes rt record the changes of the subcommands in the result of the cli
+func (c cli) recordIn(rt plugin.Runtime) plugin.Runtime {
+	recordFile, recordGenerator, recordResource := rt.RecordFile, rt.RecordGenerator, rt.RecordResource
+	rt.RecordFile = func(change file.Change) {
+		if recordFile != nil {
+			recordFile(change)
+		}
+		c.result.recorded = append(c.result.recorded, change)
+	}
+	rt.RecordGenerator = func(name string, elapsed time.Duration) {
+		if recordGenerator != nil {
+			recordGenerator(name, elapsed)
+		}
+		c.result.Generators = append(c.result.Generators, Generator{
+			Name:           name,
+			DurationMillis: int64(elapsed / time.Millisecond),
+		})
+	}
+	rt.RecordResource = func(gvk config.GVK) {
+		if recordResource != nil {
+			recordResource(gvk)
+		}
+		for _, recorded := range c.result.Resources {
+			if recorded == gvk {
+				return
+			}
+		}
+		c.result.Resources = append(c.result.Resources, gvk)
+	}
+	return rt
+}
+
+// setFiles sets the files of the result to the changes made to the project files and to the recorded
+// changes of the files that were not changed, e.g. the skipped ones
+func (r *Result) setFiles(changes []file.Change) {
+	files := make(map[string]file.Change, len(changes)+len(r.recorded))
+	for _, change := range r.recorded {
+		files[change.Path] = change
+	}
+	for _, change := range changes {
+		files[change.Path] = change
+	}
+
+	r.Files = make([]file.Change, 0, len(files))
+	for _, change := range files {
+		r.Files = append(r.Files, change)
+	}
+	sort.Slice(r.Files, func(i, j int) bool {
+		return r.Files[i].Path < r.Files[j].Path
+	})
+}
+
+// writeResult writes the result of the command as JSON, with err if it failed
+func (c cli) writeResult(out io.Writer, cmd *cobra.Command, err error) error {
+	if cmd != nil {
+		c.result.Command = cmd.CommandPath()
+	}
+	if err != nil {
+		// The changes of failed commands are rolled back
+		c.result.Resources = nil
+		c.result.Error = newError(err)
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if encodeErr := encoder.Encode(c.result); encodeErr != nil {
+		return fmt.Errorf("unable to write the result: %v", encodeErr)
+	}
+	return nil
+}
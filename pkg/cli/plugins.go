@@ -0,0 +1,189 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+	"github.com/seamounts/kubeapi/pkg/plugin/external"
+)
+
+func (c *cli) newPluginsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "plugins",
+		Short: "List and inspect the available plugins",
+		Long: `List and inspect the plugins compiled into the cli and the external plugins found in PATH and
+in the kubeapi/plugins directory of the user configuration directory.`,
+	}
+}
+
+func (c *cli) newListPluginsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the available plugins",
+		Long: `List the available plugins, with the project versions they support, the subcommands they implement
+and where they come from. Plugins marked with * run the subcommands of the current project.`,
+		Example: fmt.Sprintf(`  # List the available plugins
+  %s plugins list
+`, c.commandName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c.loadExternalPlugins(nil)
+			if c.jsonOutputEnabled() {
+				for _, p := range c.plugins {
+					c.result.AvailablePlugins = append(c.result.AvailablePlugins, c.pluginInfo(p))
+				}
+				return nil
+			}
+			c.listPlugins(cmd.OutOrStdout())
+			return nil
+		},
+	}
+}
+
+func (c *cli) newDescribePluginCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "describe <key>",
+		Short: "Print the help and flags of the subcommands of a plugin",
+		Long: `Print the help and flags of every subcommand implemented by a plugin, selected by its key, name or
+short name.`,
+		Example: fmt.Sprintf(`  # Describe the default plugin
+  %s plugins describe go.kubeapi.io/v1.0.0
+`, c.commandName),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.loadSelectedExternalPlugins(args[0])
+			p, err := c.lookupPlugin(args[0])
+			if err != nil {
+				return err
+			}
+			if c.jsonOutputEnabled() {
+				c.result.AvailablePlugins = []PluginInfo{c.pluginInfo(p)}
+				return nil
+			}
+			c.describePlugin(cmd.OutOrStdout(), p)
+			return nil
+		},
+	}
+}
+
+func (c cli) listPlugins(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tPROJECT VERSIONS\tSUBCOMMANDS\tSOURCE")
+	for _, p := range c.plugins {
+		key := plugin.KeyFor(p)
+		if c.isResolved(p) {
+			key += " *"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", key, strings.Join(p.SupportedProjectVersions(), ", "),
+			strings.Join(implementedSubcommands(p), ", "), c.pluginSource(p))
+	}
+	_ = w.Flush()
+
+	if len(c.externalPluginErrs) != 0 {
+		fmt.Fprintln(out, "\nIgnored executables:")
+		for _, err := range c.externalPluginErrs {
+			fmt.Fprintf(out, "  %v\n", err)
+		}
+	}
+}
+
+func (c cli) describePlugin(out io.Writer, p plugin.Base) {
+	fmt.Fprintf(out, "Plugin: %s\n", plugin.KeyFor(p))
+	fmt.Fprintf(out, "Source: %s\n", c.pluginSource(p))
+	fmt.Fprintf(out, "Supported project versions: %s\n", strings.Join(p.SupportedProjectVersions(), ", "))
+
+	for _, subcommand := range subcommands {
+		sub := subcommand.get(p)
+		if sub == nil {
+			continue
+		}
+
+		version := c.projectVersion
+		if !supportsProjectVersion(p, version) {
+			version = p.SupportedProjectVersions()[0]
+		}
+		sub.InjectConfig(&config.Config{Version: version})
+		fs := pflag.NewFlagSet(subcommand.name, pflag.ContinueOnError)
+		sub.BindFlags(fs)
+		ctx := plugin.Context{CommandName: c.commandName}
+		sub.UpdateContext(&ctx)
+
+		fmt.Fprintf(out, "\n%s %s\n\n", c.commandName, subcommand.name)
+		if ctx.Description != "" {
+			fmt.Fprintf(out, "%s\n", strings.TrimRight(ctx.Description, "\n"))
+		}
+		if ctx.Examples != "" {
+			fmt.Fprintf(out, "\nExamples:\n%s\n", strings.TrimRight(ctx.Examples, " \t\n"))
+		}
+		if fs.HasFlags() {
+			fmt.Fprintf(out, "\nFlags:\n%s", fs.FlagUsages())
+		}
+	}
+}
+
+// pluginInfo returns the description of p written with --output json
+func (c cli) pluginInfo(p plugin.Base) PluginInfo {
+	return PluginInfo{
+		Key:             plugin.KeyFor(p),
+		ProjectVersions: p.SupportedProjectVersions(),
+		Subcommands:     implementedSubcommands(p),
+		Source:          c.pluginSource(p),
+		Selected:        c.isResolved(p),
+	}
+}
+
+// isResolved returns true if p runs the subcommands of the current project
+func (c cli) isResolved(p plugin.Base) bool {
+	for _, resolved := range c.resolvedPlugins {
+		if plugin.KeyFor(resolved) == plugin.KeyFor(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// implementedSubcommands returns the names of the subcommands implemented by p
+func implementedSubcommands(p plugin.Base) []string {
+	var names []string
+	for _, subcommand := range subcommands {
+		if subcommand.get(p) != nil {
+			names = append(names, subcommand.name)
+		}
+	}
+	return names
+}
+
+// pluginSource returns where p comes from
+func (c cli) pluginSource(p plugin.Base) string {
+	source := "built-in"
+	if ext, isExternal := p.(*external.Plugin); isExternal {
+		source = ext.Path()
+	}
+	if c.defaultPlugin != nil && plugin.KeyFor(p) == plugin.KeyFor(c.defaultPlugin) {
+		source += " (default)"
+	}
+	return source
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/seamounts/kubeapi/internal/config"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+)
+
+func (c *cli) newRefactorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "refactor",
+		Short: "Rename or move existing Kubernetes APIs",
+		Long:  `Rename or move existing Kubernetes APIs, updating their references and regenerating derived code.`,
+	}
+}
+
+func (c *cli) newRenameKindCmd() *cobra.Command {
+	ctx := c.newRefactorContext(`Rename the Kind of an existing API.
+`)
+	cmd := &cobra.Command{
+		Use:     "rename-kind",
+		Short:   "Rename the Kind of an existing API",
+		Long:    ctx.Description,
+		Example: ctx.Examples,
+		RunE: errCmdFunc(
+			fmt.Errorf("rename-kind subcommand requires an existing project"),
+		),
+	}
+
+	// Lookup the plugin for projectVersion and bind it to the command.
+	c.bindRenameKind(ctx, cmd)
+	return cmd
+}
+
+func (c *cli) newMoveKindCmd() *cobra.Command {
+	ctx := c.newRefactorContext(`Move the Kind of an existing API to another group and / or version.
+`)
+	cmd := &cobra.Command{
+		Use:     "move-kind",
+		Short:   "Move the Kind of an existing API to another group and / or version",
+		Long:    ctx.Description,
+		Example: ctx.Examples,
+		RunE: errCmdFunc(
+			fmt.Errorf("move-kind subcommand requires an existing project"),
+		),
+	}
+
+	// Lookup the plugin for projectVersion and bind it to the command.
+	c.bindMoveKind(ctx, cmd)
+	return cmd
+}
+
+func (c cli) newRefactorContext(description string) plugin.Context {
+	ctx := plugin.Context{
+		CommandName: c.commandName,
+		Description: description,
+	}
+	if !c.configured {
+		ctx.Description = fmt.Sprintf("%s\n%s", ctx.Description, runInProjectRootMsg)
+	}
+	return ctx
+}
+
+func (c cli) bindRenameKind(ctx plugin.Context, cmd *cobra.Command) {
+	ch, err := c.newChain(getRenameKind, "kind renaming")
+	if err != nil {
+		cmdErr(cmd, err)
+		return
+	}
+
+	cfg, err := config.LoadInitializedFromFs(c.runtime.FS)
+	if err != nil {
+		cmdErr(cmd, err)
+		return
+	}
+
+	c.bindChain(ctx, cmd, cfg, ch,
+		fmt.Sprintf("failed to rename kind with version %q", c.projectVersion))
+}
+
+func (c cli) bindMoveKind(ctx plugin.Context, cmd *cobra.Command) {
+	ch, err := c.newChain(getMoveKind, "kind moving")
+	if err != nil {
+		cmdErr(cmd, err)
+		return
+	}
+
+	cfg, err := config.LoadInitializedFromFs(c.runtime.FS)
+	if err != nil {
+		cmdErr(cmd, err)
+		return
+	}
+
+	c.bindChain(ctx, cmd, cfg, ch,
+		fmt.Sprintf("failed to move kind with version %q", c.projectVersion))
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"github.com/seamounts/kubeapi/pkg/plugin"
+)
+
+// subcommandGetter returns the subcommand of a plugin, or nil if the plugin does not implement it.
+type subcommandGetter func(plugin.Base) plugin.GenericSubcommand
+
+// subcommands are the subcommands that plugins can implement, by command path.
+var subcommands = []struct {
+	name string
+	get  subcommandGetter
+}{
+	{"init", getInit},
+	{"create api", getCreateAPI},
+	{"create apis", getCreateAPIs},
+	{"refactor rename-kind", getRenameKind},
+	{"refactor move-kind", getMoveKind},
+	{"import crd", getImportCRD},
+	{"generate client", getGenerateClient},
+	{"templates export", getExportTemplates},
+}
+
+func getInit(p plugin.Base) plugin.GenericSubcommand {
+	if getter, isGetter := p.(plugin.InitPluginGetter); isGetter {
+		if init := getter.GetInitPlugin(); init != nil {
+			return init
+		}
+	}
+	return nil
+}
+
+func getCreateAPI(p plugin.Base) plugin.GenericSubcommand {
+	if getter, isGetter := p.(plugin.CreateAPIPluginGetter); isGetter {
+		if createAPI := getter.GetCreateAPIPlugin(); createAPI != nil {
+			return createAPI
+		}
+	}
+	return nil
+}
+
+func getCreateAPIs(p plugin.Base) plugin.GenericSubcommand {
+	if getter, isGetter := p.(plugin.CreateAPIsPluginGetter); isGetter {
+		if createAPIs := getter.GetCreateAPIsPlugin(); createAPIs != nil {
+			return createAPIs
+		}
+	}
+	return nil
+}
+
+func getRenameKind(p plugin.Base) plugin.GenericSubcommand {
+	if getter, isGetter := p.(plugin.RenameKindPluginGetter); isGetter {
+		if renameKind := getter.GetRenameKindPlugin(); renameKind != nil {
+			return renameKind
+		}
+	}
+	return nil
+}
+
+func getMoveKind(p plugin.Base) plugin.GenericSubcommand {
+	if getter, isGetter := p.(plugin.MoveKindPluginGetter); isGetter {
+		if moveKind := getter.GetMoveKindPlugin(); moveKind != nil {
+			return moveKind
+		}
+	}
+	return nil
+}
+
+func getImportCRD(p plugin.Base) plugin.GenericSubcommand {
+	if getter, isGetter := p.(plugin.ImportCRDPluginGetter); isGetter {
+		if importCRD := getter.GetImportCRDPlugin(); importCRD != nil {
+			return importCRD
+		}
+	}
+	return nil
+}
+
+func getGenerateClient(p plugin.Base) plugin.GenericSubcommand {
+	if getter, isGetter := p.(plugin.GenerateClientPluginGetter); isGetter {
+		if generateClient := getter.GetGenerateClientPlugin(); generateClient != nil {
+			return generateClient
+		}
+	}
+	return nil
+}
+
+func getExportTemplates(p plugin.Base) plugin.GenericSubcommand {
+	if getter, isGetter := p.(plugin.ExportTemplatesPluginGetter); isGetter {
+		if exportTemplates := getter.GetExportTemplatesPlugin(); exportTemplates != nil {
+			return exportTemplates
+		}
+	}
+	return nil
+}
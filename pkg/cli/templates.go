@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/seamounts/kubeapi/internal/config"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+)
+
+func (c *cli) newTemplatesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "templates",
+		Short: "Manage the templates used to scaffold files",
+		Long:  `Manage the templates used to scaffold files, which can be overridden per project.`,
+	}
+}
+
+func (c *cli) newExportTemplatesCmd() *cobra.Command {
+	ctx := plugin.Context{
+		CommandName: c.commandName,
+		Description: `Export the built-in templates as a starting point to override them.
+`,
+	}
+	if !c.configured {
+		ctx.Description = fmt.Sprintf("%s\n%s", ctx.Description, runInProjectRootMsg)
+	}
+
+	cmd := &cobra.Command{
+		Use:     "export",
+		Short:   "Export the built-in templates as a starting point to override them",
+		Long:    ctx.Description,
+		Example: ctx.Examples,
+		RunE: errCmdFunc(
+			fmt.Errorf("export subcommand requires an existing project"),
+		),
+	}
+
+	// Lookup the plugin for projectVersion and bind it to the command.
+	c.bindExportTemplates(ctx, cmd)
+	return cmd
+}
+
+func (c cli) bindExportTemplates(ctx plugin.Context, cmd *cobra.Command) {
+	ch, err := c.newChain(getExportTemplates, "templates exporting")
+	if err != nil {
+		cmdErr(cmd, err)
+		return
+	}
+
+	cfg, err := config.LoadInitializedFromFs(c.runtime.FS)
+	if err != nil {
+		cmdErr(cmd, err)
+		return
+	}
+
+	c.bindChain(ctx, cmd, cfg, ch,
+		fmt.Sprintf("failed to export templates with version %q", c.projectVersion))
+}
@@ -0,0 +1,358 @@
+package codegen
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+
+	"github.com/seamounts/kubeapi/pkg/model/kubernetes"
+)
+
+// metav1Path is the import path of the options of the client methods
+const metav1Path = "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// contextAwareCalls are the methods of the requests of client-go that take a context.Context since Kubernetes 1.18
+var contextAwareCalls = map[string]struct{}{
+	"Do":     {},
+	"DoRaw":  {},
+	"Stream": {},
+	"Watch":  {},
+}
+
+// clientVerbs are the methods of the typed clients that take a context.Context with the context client style,
+// with the options they take too, if any
+var clientVerbs = map[string]string{
+	"Create":           "CreateOptions",
+	"Update":           "UpdateOptions",
+	"UpdateStatus":     "UpdateOptions",
+	"Patch":            "PatchOptions",
+	"Delete":           "",
+	"DeleteCollection": "",
+	"Get":              "",
+	"List":             "",
+	"Watch":            "",
+}
+
+// rewriter rewrites the syntax tree of a generated file, and returns true if it changed it
+type rewriter func(fset *token.FileSet, f *ast.File) bool
+
+// adapt returns the content of the generated file at path, relative to the project root, adapted to the
+// Kubernetes version the project targets and to the style of its clients. The generators produce code for the
+// client-go of Kubernetes 1.15.
+func (gen *CodeGen) adapt(path string, content []byte) ([]byte, error) {
+	if gen.config.KubernetesVersion == "" || filepath.Ext(path) != ".go" {
+		return content, nil
+	}
+	release, err := kubernetes.Lookup(gen.config.KubernetesVersion)
+	if err != nil {
+		return nil, err
+	}
+	if !release.ContextAwareClients() {
+		return content, nil
+	}
+
+	clientsetDir := filepath.Join(gen.output, CLIENTSET_PKG_NAME) + string(filepath.Separator)
+	typedDir := filepath.Join(gen.output, CLIENTSET_PKG_NAME, CLIENTSET_NAME_VERSIONED, "typed") + string(filepath.Separator)
+	informersDir := filepath.Join(gen.output, "informers") + string(filepath.Separator)
+	switch {
+	case gen.config.ContextAwareClients() && strings.HasPrefix(path, typedDir):
+		content, err := passOptions(path, content)
+		if err != nil {
+			return nil, err
+		}
+		return rewrite(path, content, takeContexts, passContexts)
+	case gen.config.ContextAwareClients() && strings.HasPrefix(path, informersDir):
+		return rewrite(path, content, passContextsToClients)
+	case strings.HasPrefix(path, clientsetDir):
+		return rewrite(path, content, passContexts)
+	}
+	return content, nil
+}
+
+// rewrite returns src rewritten by the provided rewriters, in order
+func rewrite(path string, src []byte, rewriters ...rewriter) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+	for _, rewrite := range rewriters {
+		if rewrite(fset, f) {
+			changed = true
+		}
+	}
+	if !changed {
+		return src, nil
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, f); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// passOptions makes the requests of the methods of the typed clients in src that take options pass the opts
+// parameter, which takeContexts adds, as parameters. The call is inserted in the source, on a line of its
+// own, for the chain of calls of the requests to keep a call per line.
+func passOptions(path string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// The offsets of the Body calls of the requests, in order
+	var offsets []int
+	for _, decl := range f.Decls {
+		funcDecl, isFunc := decl.(*ast.FuncDecl)
+		if !isFunc || funcDecl.Recv == nil || funcDecl.Body == nil || clientVerbs[funcDecl.Name.Name] == "" {
+			continue
+		}
+		ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+			if selector, isSelector := n.(*ast.SelectorExpr); isSelector && selector.Sel.Name == "Body" {
+				offsets = append(offsets, fset.Position(selector.Sel.Pos()).Offset)
+			}
+			return true
+		})
+	}
+
+	var buf bytes.Buffer
+	last := 0
+	for _, offset := range offsets {
+		lineStart := bytes.LastIndexByte(src[:offset], '\n') + 1
+		indent := src[lineStart:offset]
+		if len(bytes.TrimSpace(indent)) != 0 {
+			// The call is not the first of its line
+			indent = nil
+			lineStart = offset
+		}
+		buf.Write(src[last:lineStart])
+		buf.Write(indent)
+		buf.WriteString("VersionedParams(&opts, scheme.ParameterCodec).")
+		if indent != nil {
+			buf.WriteString("\n")
+		}
+		last = lineStart
+	}
+	buf.Write(src[last:])
+	return buf.Bytes(), nil
+}
+
+// passContexts passes context.TODO() to the requests the clients in f make, which do not pass any context
+func passContexts(fset *token.FileSet, f *ast.File) bool {
+	changed := false
+	ast.Inspect(f, func(n ast.Node) bool {
+		if call, isCall := n.(*ast.CallExpr); isCall && isContextAwareCall(call) {
+			call.Args = []ast.Expr{contextTODO()}
+			changed = true
+		}
+		return true
+	})
+	if changed {
+		astutil.AddImport(fset, f, "context")
+	}
+	return changed
+}
+
+// passContextsToClients passes context.TODO() to the List and Watch calls of the typed clients the informers in
+// f make, which take a context.Context with the context client style
+func passContextsToClients(fset *token.FileSet, f *ast.File) bool {
+	changed := false
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, isCall := n.(*ast.CallExpr)
+		if !isCall || len(call.Args) != 1 {
+			return true
+		}
+		selector, isSelector := call.Fun.(*ast.SelectorExpr)
+		if !isSelector || (selector.Sel.Name != "List" && selector.Sel.Name != "Watch") {
+			return true
+		}
+		// The typed clients are returned by the getters of the clientset, e.g. client.ShipV1beta1().Frigates(namespace)
+		if _, isClient := selector.X.(*ast.CallExpr); !isClient {
+			return true
+		}
+		call.Args = append([]ast.Expr{contextTODO()}, call.Args...)
+		changed = true
+		return true
+	})
+	if changed {
+		astutil.AddImport(fset, f, "context")
+	}
+	return changed
+}
+
+// takeContexts makes the methods of the typed clients in f, and of their interfaces, take a context.Context
+// first and the options of client-go 1.18, and makes their requests pass the context
+func takeContexts(fset *token.FileSet, f *ast.File) bool {
+	metav1 := importName(f, metav1Path)
+	if metav1 == "" {
+		metav1 = "metav1"
+	}
+
+	changed := false
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if _, isVerb := clientVerbs[decl.Name.Name]; !isVerb || decl.Recv == nil || decl.Body == nil {
+				continue
+			}
+			deleteOptions := takeContext(decl.Name.Name, decl.Type, metav1)
+			passContext(decl.Body, deleteOptions)
+			changed = true
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				typeSpec, isType := spec.(*ast.TypeSpec)
+				if !isType {
+					continue
+				}
+				iface, isInterface := typeSpec.Type.(*ast.InterfaceType)
+				if !isInterface {
+					continue
+				}
+				for _, method := range iface.Methods.List {
+					funcType, isFunc := method.Type.(*ast.FuncType)
+					if !isFunc || len(method.Names) != 1 {
+						continue
+					}
+					if _, isVerb := clientVerbs[method.Names[0].Name]; isVerb {
+						takeContext(method.Names[0].Name, funcType, metav1)
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	if changed {
+		astutil.AddImport(fset, f, "context")
+		if importName(f, metav1Path) == "" {
+			astutil.AddNamedImport(fset, f, metav1, metav1Path)
+		}
+	}
+	return changed
+}
+
+// takeContext adds the context.Context and the options of the verb to the parameters of typ, and makes
+// the delete options values instead of pointers. It returns the names of the delete options parameters.
+func takeContext(verb string, typ *ast.FuncType, metav1 string) []string {
+	params := typ.Params.List
+	named := len(params) > 0 && len(params[0].Names) > 0
+	newParam := func(name string, t ast.Expr) *ast.Field {
+		if !named {
+			return &ast.Field{Type: t}
+		}
+		return &ast.Field{Names: []*ast.Ident{ast.NewIdent(name)}, Type: t}
+	}
+
+	var deleteOptions []string
+	for _, param := range params {
+		star, isPointer := param.Type.(*ast.StarExpr)
+		if !isPointer || !isSelector(star.X, metav1, "DeleteOptions") {
+			continue
+		}
+		param.Type = star.X
+		for _, name := range param.Names {
+			deleteOptions = append(deleteOptions, name.Name)
+		}
+	}
+
+	if options := clientVerbs[verb]; options != "" {
+		opts := newParam("opts", &ast.SelectorExpr{X: ast.NewIdent(metav1), Sel: ast.NewIdent(options)})
+		// The options go before the variadic subresources of Patch
+		if n := len(params); n > 0 {
+			if _, isVariadic := params[n-1].Type.(*ast.Ellipsis); isVariadic {
+				params = append(params[:n-1], opts, params[n-1])
+			} else {
+				params = append(params, opts)
+			}
+		} else {
+			params = append(params, opts)
+		}
+	}
+
+	ctx := newParam("ctx", &ast.SelectorExpr{X: ast.NewIdent("context"), Sel: ast.NewIdent("Context")})
+	typ.Params.List = append([]*ast.Field{ctx}, params...)
+	return deleteOptions
+}
+
+// passContext makes the requests in body pass the ctx parameter and the delete options by reference
+func passContext(body *ast.BlockStmt, deleteOptions []string) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, isCall := n.(*ast.CallExpr)
+		if !isCall {
+			return true
+		}
+		if isContextAwareCall(call) {
+			call.Args = []ast.Expr{ast.NewIdent("ctx")}
+			return true
+		}
+
+		selector, isSelector := call.Fun.(*ast.SelectorExpr)
+		if !isSelector || selector.Sel.Name != "Body" || len(call.Args) != 1 {
+			return true
+		}
+		if arg, isIdent := call.Args[0].(*ast.Ident); isIdent && contains(deleteOptions, arg.Name) {
+			call.Args[0] = &ast.UnaryExpr{Op: token.AND, X: arg}
+		}
+		return true
+	})
+}
+
+// isContextAwareCall returns true if call is a request of client-go that does not pass any context
+func isContextAwareCall(call *ast.CallExpr) bool {
+	if len(call.Args) != 0 {
+		return false
+	}
+	selector, isSelector := call.Fun.(*ast.SelectorExpr)
+	if !isSelector {
+		return false
+	}
+	_, found := contextAwareCalls[selector.Sel.Name]
+	return found
+}
+
+// isSelector returns true if expr is pkg.name
+func isSelector(expr ast.Expr, pkg, name string) bool {
+	selector, isSelector := expr.(*ast.SelectorExpr)
+	if !isSelector || selector.Sel.Name != name {
+		return false
+	}
+	ident, isIdent := selector.X.(*ast.Ident)
+	return isIdent && ident.Name == pkg
+}
+
+// importName returns the name the package at path is imported with in f, empty if f does not import it
+func importName(f *ast.File, path string) string {
+	for _, imp := range f.Imports {
+		if importPath, err := strconv.Unquote(imp.Path.Value); err != nil || importPath != path {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return filepath.Base(path)
+	}
+	return ""
+}
+
+func contextTODO() ast.Expr {
+	return &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent("context"), Sel: ast.NewIdent("TODO")}}
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package codegen_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/seamounts/kubeapi/pkg/codegen"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/scaffoldtest"
+)
+
+func TestAdapt(t *testing.T) {
+	const (
+		typedPath    = "client/clientset/versioned/typed/ship/v1beta1/frigate.go"
+		informerPath = "client/informers/externalversions/ship/v1beta1/frigate.go"
+	)
+
+	for name, test := range map[string]struct {
+		input       string
+		path        string
+		clientStyle string
+	}{
+		"typed-legacy":     {input: "typed.go", path: typedPath, clientStyle: config.ClientStyleLegacy},
+		"typed-context":    {input: "typed.go", path: typedPath, clientStyle: config.ClientStyleContext},
+		"informer-context": {input: "informer.go", path: informerPath, clientStyle: config.ClientStyleContext},
+	} {
+		t.Run(name, func(t *testing.T) {
+			input, err := ioutil.ReadFile(filepath.Join("testdata", test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gen := codegen.NewCodeGen(&config.Config{
+				Repo:              "example.com/project",
+				KubernetesVersion: "1.28",
+				ClientStyle:       test.clientStyle,
+			})
+			output, err := gen.Adapt(filepath.FromSlash(test.path), input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			scaffoldtest.AssertGolden(t, filepath.Join("testdata", name), map[string]string{test.path: string(output)})
+		})
+	}
+}
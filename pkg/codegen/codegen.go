@@ -1,21 +1,32 @@
 package codegen
 
 import (
+	"bytes"
 	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/seamounts/kubeapi/pkg/codegen/clientset"
 	"github.com/seamounts/kubeapi/pkg/codegen/deepcopy"
 	"github.com/seamounts/kubeapi/pkg/codegen/informar"
 	"github.com/seamounts/kubeapi/pkg/codegen/lister"
 	"github.com/seamounts/kubeapi/pkg/model/config"
 	"github.com/seamounts/kubeapi/pkg/model/resource"
+	"github.com/spf13/afero"
 	clientsetargs "k8s.io/code-generator/cmd/client-gen/args"
 	deepcopyaargs "k8s.io/code-generator/cmd/deepcopy-gen/args"
 	informarargs "k8s.io/code-generator/cmd/informer-gen/args"
 	listerargs "k8s.io/code-generator/cmd/lister-gen/args"
 	"k8s.io/gengo/args"
 	deepcopygenerators "k8s.io/gengo/examples/deepcopy-gen/generators"
-	"k8s.io/klog/v2"
+	"k8s.io/klog/v2/klogr"
 )
 
 const (
@@ -23,96 +34,335 @@ const (
 	CLIENTSET_PKG_NAME       = "clientset"
 	OUTPUT_DIR               = "client"
 	INPUT_DIR                = "apis"
+
+	// boilerplatePath is the header of the generated files, relative to the project root
+	boilerplatePath = "hack/boilerplate.go.txt"
 )
 
 type CodeGen struct {
-	config   *config.Config
-	resource *resource.Resource
+	config    *config.Config
+	resources []*resource.Resource
+
+	// inputs are the go packages of the API types of other modules the clients are generated for, if set instead
+	// of resources
+	inputs []string
+	// output is the directory the clients are generated in, relative to the project root
+	output string
 
-	inputDir  string
-	outputDir string
+	// fs is the file system of the project, which the generated files are written to
+	fs afero.Fs
+	// root is the directory of the project on disk, which the generators load the API types from, the working
+	// directory if empty
+	root string
+	// outputBase is the directory the generators write to before the files are copied to fs
+	outputBase string
+	// record, if set, is called after each generator ran
+	record func(name string, elapsed time.Duration)
+	// logger logs which generators run
+	logger logr.Logger
+}
+
+// NewCodeGen returns a CodeGen that generates the code for the group-versions of the provided resources
+func NewCodeGen(config *config.Config, resources ...*resource.Resource) *CodeGen {
+	return &CodeGen{
+		config:    config,
+		resources: resources,
+		output:    OUTPUT_DIR,
+		fs:        afero.NewOsFs(),
+		logger:    klogr.New(),
+	}
 }
 
-var defaultCodeGen *CodeGen
+// NewExternalCodeGen returns a CodeGen that generates the clients of the API types of the provided go packages of
+// other modules, e.g. "github.com/acme/apis/foo/v1", in the output directory relative to the project root. The
+// packages are resolved through the module graph of the project, and their deepcopy funcs are not generated.
+func NewExternalCodeGen(config *config.Config, inputPackages []string, output string) *CodeGen {
+	return &CodeGen{
+		config: config,
+		inputs: inputPackages,
+		output: output,
+		fs:     afero.NewOsFs(),
+		logger: klogr.New(),
+	}
+}
 
+// GetCodeGen returns a CodeGen that generates the code for the group-version of the provided resource options
 func GetCodeGen(config *config.Config, opt *resource.Options) *CodeGen {
-	if defaultCodeGen == nil {
-		defaultCodeGen = &CodeGen{
-			config:   config,
-			resource: opt.NewResource(config),
+	return NewCodeGen(config, opt.NewResource(config))
+}
+
+// NewProjectCodeGen returns a CodeGen that generates the code for every group-version found in the project of fs
+func NewProjectCodeGen(config *config.Config, fs afero.Fs) (*CodeGen, error) {
+	groups, err := afero.ReadDir(fs, INPUT_DIR)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []*resource.Resource
+	for _, group := range groups {
+		if !group.IsDir() {
+			continue
+		}
+		versions, err := afero.ReadDir(fs, filepath.Join(INPUT_DIR, group.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range versions {
+			if !version.IsDir() {
+				continue
+			}
+			resources = append(resources, &resource.Resource{Group: group.Name(), Version: version.Name()})
 		}
 	}
 
-	return defaultCodeGen
+	gen := NewCodeGen(config, resources...)
+	gen.InjectFS(fs)
+	return gen, nil
 }
 
-func (gen *CodeGen) Run() error {
+// InjectFS sets the file system the generated files are written to
+func (gen *CodeGen) InjectFS(fs afero.Fs) {
+	gen.fs = fs
+}
 
-	outputpkg := fmt.Sprintf("%s/%s", defaultCodeGen.config.Repo, OUTPUT_DIR)
+// InjectProjectRoot sets the directory of the project on disk, which defaults to the working directory
+func (gen *CodeGen) InjectProjectRoot(dir string) {
+	gen.root = dir
+}
 
-	klog.Infoln("Generating deepcopy funcs")
-	dc, err := deepcopy.NewDeepCopy(deepCopyOptions)
-	if err != nil {
+// InjectRecord sets the function called after each generator ran, with how long it took
+func (gen *CodeGen) InjectRecord(record func(name string, elapsed time.Duration)) {
+	gen.record = record
+}
+
+// InjectLogger sets the logger of the generators that run, which defaults to klog
+func (gen *CodeGen) InjectLogger(logger logr.Logger) {
+	gen.logger = logger
+}
+
+// Run generates the code in a temporary directory and then copies it to the project
+func (gen *CodeGen) Run() error {
+	if err := gen.config.ValidateClientStyle(); err != nil {
 		return err
 	}
-	if err := dc.Run(); err != nil {
-		return err
+
+	// The generators exit the process if they cannot load the boilerplate
+	boilerplate, err := afero.ReadFile(gen.fs, boilerplatePath)
+	if err != nil {
+		return fmt.Errorf("unable to load boilerplate: %v", err)
 	}
 
-	klog.Infof("Generating clientset for %s/%s at %s/%s", gen.resource.Group, gen.resource.Version,
-		outputpkg, CLIENTSET_PKG_NAME)
-	cs, err := clientset.NewClientSet(clientsetOptions)
+	outputBase, err := ioutil.TempDir("", "kubeapi-codegen-")
 	if err != nil {
 		return err
 	}
-	if err := cs.Run(); err != nil {
+	defer os.RemoveAll(outputBase)
+	gen.outputBase = outputBase
+
+	// The generators read the boilerplate from the disk, and the project file system may not be on it
+	if err := ioutil.WriteFile(gen.boilerplatePath(), boilerplate, 0644); err != nil {
 		return err
 	}
 
-	klog.Infof("Generating listers for %s/%s at %s/listers", gen.resource.Group, gen.resource.Version, outputpkg)
-	li, err := lister.NewLister(listerOptions)
-	if err != nil {
+	// The generators load the API types with the go tool through the default build context, which resolves the
+	// packages of the project module from its directory, the working directory if unset
+	if gen.root != "" {
+		dir := build.Default.Dir
+		build.Default.Dir = gen.root
+		defer func() { build.Default.Dir = dir }()
+	}
+
+	if err := gen.resolveInputs(); err != nil {
 		return err
 	}
-	if err := li.Run(); err != nil {
+	if err := gen.generate(); err != nil {
 		return err
 	}
 
-	klog.Infof("Generating informers for %s/%s at %s/informers", gen.resource.Group, gen.resource.Version, outputpkg)
-	in, err := informar.NewInformar(informarOptions)
-	if err != nil {
+	return gen.copyOutput()
+}
+
+// boilerplatePath returns the path of the copy of the boilerplate the generators read
+func (gen *CodeGen) boilerplatePath() string {
+	return filepath.Join(gen.outputBase, filepath.Base(boilerplatePath))
+}
+
+// resolveInputs returns an error if the input packages of other modules cannot be resolved through the module
+// graph of the project
+func (gen *CodeGen) resolveInputs() error {
+	if len(gen.inputs) == 0 {
+		return nil
+	}
+	cmd := exec.Command("go", append([]string{"list", "-find"}, gen.inputs...)...)
+	cmd.Dir = gen.root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("unable to resolve the input packages, add their modules to the project with go get: "+
+			"%v\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func (gen *CodeGen) generate() error {
+	outputpkg := gen.outputPackage()
+	groupVersions := strings.Join(gen.groupVersions(), ", ")
+
+	// The deepcopy funcs of the packages of other modules are part of them
+	if len(gen.inputs) == 0 {
+		gen.logger.Info("Generating deepcopy funcs")
+		if err := gen.run("deepcopy", func() error {
+			dc, err := deepcopy.NewDeepCopy(gen.deepCopyOptions)
+			if err != nil {
+				return err
+			}
+			return dc.Run()
+		}); err != nil {
+			return err
+		}
+	}
+
+	gen.logger.Info("Generating clientset", "groupVersions", groupVersions,
+		"package", path.Join(outputpkg, CLIENTSET_PKG_NAME))
+	if err := gen.run("clientset", func() error {
+		cs, err := clientset.NewClientSet(gen.clientsetOptions)
+		if err != nil {
+			return err
+		}
+		return cs.Run()
+	}); err != nil {
 		return err
 	}
-	if err := in.Run(); err != nil {
+
+	gen.logger.Info("Generating listers", "groupVersions", groupVersions, "package", path.Join(outputpkg, "listers"))
+	if err := gen.run("lister", func() error {
+		li, err := lister.NewLister(gen.listerOptions)
+		if err != nil {
+			return err
+		}
+		return li.Run()
+	}); err != nil {
 		return err
 	}
 
+	gen.logger.Info("Generating informers", "groupVersions", groupVersions, "package", path.Join(outputpkg, "informers"))
+	return gen.run("informer", func() error {
+		in, err := informar.NewInformar(gen.informarOptions)
+		if err != nil {
+			return err
+		}
+		return in.Run()
+	})
+}
+
+// run runs the generator with the provided name, recording how long it took if it succeeded
+func (gen *CodeGen) run(name string, generator func() error) error {
+	start := time.Now()
+	if err := generator(); err != nil {
+		return fmt.Errorf("%s generator failed: %w", name, err)
+	}
+	if gen.record != nil {
+		gen.record(name, time.Since(start))
+	}
 	return nil
 }
 
-func deepCopyOptions(genericArgs *args.GeneratorArgs, customArgs *deepcopyaargs.CustomArgs) error {
-	genericArgs.InputDirs = append(genericArgs.InputDirs, fmt.Sprintf("%s/%s/%s/%s",
-		defaultCodeGen.config.Repo, INPUT_DIR, defaultCodeGen.resource.Group, defaultCodeGen.resource.Version))
+// copyOutput copies the generated files of the project packages to the project
+func (gen *CodeGen) copyOutput() error {
+	root := filepath.Join(gen.outputBase, filepath.FromSlash(gen.config.Repo))
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && path == root {
+			return nil
+		}
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadFile(path) // nolint:gosec
+		if err != nil {
+			return err
+		}
+		if content, err = gen.adapt(rel, content); err != nil {
+			return fmt.Errorf("unable to adapt %s to Kubernetes %s: %v", rel, gen.config.KubernetesVersion, err)
+		}
+		if err := gen.fs.MkdirAll(filepath.Dir(rel), 0755); err != nil {
+			return err
+		}
+		return afero.WriteFile(gen.fs, rel, content, 0644)
+	})
+}
+
+// outputPackage returns the go package the clients are generated in
+func (gen *CodeGen) outputPackage() string {
+	return path.Join(gen.config.Repo, filepath.ToSlash(gen.output))
+}
+
+// groupVersions returns the distinct group-versions of the resources, or of the input packages, in order
+func (gen *CodeGen) groupVersions() []string {
+	if len(gen.inputs) != 0 {
+		gvs := make([]string, 0, len(gen.inputs))
+		for _, pkg := range gen.inputs {
+			gvs = append(gvs, path.Join(path.Base(path.Dir(pkg)), path.Base(pkg)))
+		}
+		return gvs
+	}
+
+	seen := make(map[string]struct{}, len(gen.resources))
+	gvs := make([]string, 0, len(gen.resources))
+	for _, res := range gen.resources {
+		gv := fmt.Sprintf("%s/%s", res.Group, res.Version)
+		if _, found := seen[gv]; found {
+			continue
+		}
+		seen[gv] = struct{}{}
+		gvs = append(gvs, gv)
+	}
+	return gvs
+}
+
+// inputPackages returns the go packages that contain the API types of every group-version
+func (gen *CodeGen) inputPackages() []string {
+	if len(gen.inputs) != 0 {
+		return gen.inputs
+	}
+	gvs := gen.groupVersions()
+	pkgs := make([]string, 0, len(gvs))
+	for _, gv := range gvs {
+		pkgs = append(pkgs, fmt.Sprintf("%s/%s/%s", gen.config.Repo, INPUT_DIR, gv))
+	}
+	return pkgs
+}
+
+func (gen *CodeGen) deepCopyOptions(genericArgs *args.GeneratorArgs, customArgs *deepcopyaargs.CustomArgs) error {
+	genericArgs.OutputBase = gen.outputBase
+	genericArgs.GoHeaderFilePath = gen.boilerplatePath()
+	genericArgs.InputDirs = append(genericArgs.InputDirs, gen.inputPackages()...)
 
 	genericArgs.OutputFileBaseName = "zz_generated.deepcopy"
 	genericArgs.CustomArgs = &deepcopygenerators.CustomArgs{
 		BoundingDirs: []string{
-			fmt.Sprintf("%s/%s", defaultCodeGen.config.Repo, INPUT_DIR),
+			fmt.Sprintf("%s/%s", gen.config.Repo, INPUT_DIR),
 		},
 	}
 
 	return nil
 }
 
-func clientsetOptions(genericArgs *args.GeneratorArgs, customArgs *clientsetargs.CustomArgs) error {
+func (gen *CodeGen) clientsetOptions(genericArgs *args.GeneratorArgs, customArgs *clientsetargs.CustomArgs) error {
+	genericArgs.OutputBase = gen.outputBase
+	genericArgs.GoHeaderFilePath = gen.boilerplatePath()
 	customArgs.ClientsetName = CLIENTSET_NAME_VERSIONED
-	genericArgs.OutputPackagePath = fmt.Sprintf("%s/%s/%s", defaultCodeGen.config.Repo,
-		OUTPUT_DIR, CLIENTSET_PKG_NAME)
+	genericArgs.OutputPackagePath = fmt.Sprintf("%s/%s", gen.outputPackage(), CLIENTSET_PKG_NAME)
 
 	gvPackages := clientsetargs.NewGVPackagesValue(clientsetargs.NewGroupVersionsBuilder(&customArgs.Groups), nil)
 
-	gvPackages.Set(fmt.Sprintf("%s/%s/%s/%s", defaultCodeGen.config.Repo,
-		INPUT_DIR, defaultCodeGen.resource.Group, defaultCodeGen.resource.Version))
+	if err := gvPackages.Set(strings.Join(gen.inputPackages(), ",")); err != nil {
+		return err
+	}
 
 	// add group version package as input dirs for gengo
 	for _, pkg := range customArgs.Groups {
@@ -126,25 +376,27 @@ func clientsetOptions(genericArgs *args.GeneratorArgs, customArgs *clientsetargs
 	return nil
 }
 
-func informarOptions(genericArgs *args.GeneratorArgs, customArgs *informarargs.CustomArgs) error {
-	genericArgs.InputDirs = append(genericArgs.InputDirs, fmt.Sprintf("%s/%s/%s/%s",
-		defaultCodeGen.config.Repo, INPUT_DIR, defaultCodeGen.resource.Group, defaultCodeGen.resource.Version))
-	genericArgs.OutputPackagePath = fmt.Sprintf("%s/%s/informers", defaultCodeGen.config.Repo, OUTPUT_DIR)
+func (gen *CodeGen) informarOptions(genericArgs *args.GeneratorArgs, customArgs *informarargs.CustomArgs) error {
+	genericArgs.OutputBase = gen.outputBase
+	genericArgs.GoHeaderFilePath = gen.boilerplatePath()
+	genericArgs.InputDirs = append(genericArgs.InputDirs, gen.inputPackages()...)
+	genericArgs.OutputPackagePath = fmt.Sprintf("%s/informers", gen.outputPackage())
 
-	customArgs.VersionedClientSetPackage = fmt.Sprintf("%s/%s/%s/%s", defaultCodeGen.config.Repo,
-		OUTPUT_DIR, CLIENTSET_PKG_NAME, CLIENTSET_NAME_VERSIONED)
+	customArgs.VersionedClientSetPackage = fmt.Sprintf("%s/%s/%s", gen.outputPackage(),
+		CLIENTSET_PKG_NAME, CLIENTSET_NAME_VERSIONED)
 
-	customArgs.ListersPackage = fmt.Sprintf("%s/%s/listers", defaultCodeGen.config.Repo, OUTPUT_DIR)
+	customArgs.ListersPackage = fmt.Sprintf("%s/listers", gen.outputPackage())
 
 	genericArgs.CustomArgs = customArgs
 
 	return nil
 }
 
-func listerOptions(genericArgs *args.GeneratorArgs, customArgs *listerargs.CustomArgs) error {
-	genericArgs.InputDirs = append(genericArgs.InputDirs, fmt.Sprintf("%s/%s/%s/%s",
-		defaultCodeGen.config.Repo, INPUT_DIR, defaultCodeGen.resource.Group, defaultCodeGen.resource.Version))
-	genericArgs.OutputPackagePath = fmt.Sprintf("%s/%s/listers", defaultCodeGen.config.Repo, OUTPUT_DIR)
+func (gen *CodeGen) listerOptions(genericArgs *args.GeneratorArgs, customArgs *listerargs.CustomArgs) error {
+	genericArgs.OutputBase = gen.outputBase
+	genericArgs.GoHeaderFilePath = gen.boilerplatePath()
+	genericArgs.InputDirs = append(genericArgs.InputDirs, gen.inputPackages()...)
+	genericArgs.OutputPackagePath = fmt.Sprintf("%s/listers", gen.outputPackage())
 
 	return nil
 }
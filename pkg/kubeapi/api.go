@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeapi
+
+import (
+	"context"
+
+	internalconfig "github.com/seamounts/kubeapi/internal/config"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+)
+
+// APIOptions are the options of CreateAPI, which match the flags of `kubeapi create api`
+type APIOptions struct {
+	// Group, Version and Kind identify the resource
+	Group   string
+	Version string
+	Kind    string
+	// ClusterScoped is true if the resource is not namespaced
+	ClusterScoped bool
+	// Plural is the plural of the Kind, computed from it if empty
+	Plural string
+	// Force creates the resource even if it already exists, merging the scaffolded files with their changes
+	Force bool
+	// SkipGenerate skips running the code generators after scaffolding the files
+	SkipGenerate bool
+	// TemplatesDir is where the built-in templates are overridden, .kubeapi/templates if empty
+	TemplatesDir string
+	// SpecFields and StatusFields are the fields of the Kind as name:type[:options], e.g. "replicas:int32:required"
+	SpecFields   []string
+	StatusFields []string
+	// FieldsFile is the YAML file the fields of the Kind are loaded from, relative to the project root
+	FieldsFile string
+	// Preset is the preset of the Kind, e.g. "workload", which sets its fields, subresources and markers
+	Preset string
+
+	// Flags are the values of other flags of the plugins, by name
+	Flags map[string]string
+}
+
+// flags returns the values of the flags of the create api subcommands
+func (o APIOptions) flags() map[string][]string {
+	flags := make(map[string][]string, len(o.Flags)+10)
+	for name, value := range o.Flags {
+		flags[name] = []string{value}
+	}
+	setString(flags, "group", o.Group)
+	setString(flags, "version", o.Version)
+	setString(flags, "kind", o.Kind)
+	setBool(flags, "namespaced", !o.ClusterScoped, true)
+	setString(flags, "plural", o.Plural)
+	setBool(flags, "force", o.Force, false)
+	setBool(flags, "generate", !o.SkipGenerate, true)
+	setString(flags, "templates-dir", o.TemplatesDir)
+	setStrings(flags, "spec-field", o.SpecFields)
+	setStrings(flags, "status-field", o.StatusFields)
+	setString(flags, "from-file", o.FieldsFile)
+	setString(flags, "preset", o.Preset)
+	return flags
+}
+
+// CreateAPI creates an API in the project initialized in dir
+func CreateAPI(ctx context.Context, dir string, opts APIOptions, options ...Option) (*Result, error) {
+	o, err := newOptions(dir, options)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := internalconfig.LoadInitializedFromFs(o.rt.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	plugins, err := o.resolvePlugins(cfg.Layout)
+	if err != nil {
+		return nil, err
+	}
+	return run(ctx, o, cfg, plugins, func(p plugin.Base) plugin.GenericSubcommand {
+		if getter, isGetter := p.(plugin.CreateAPIPluginGetter); isGetter {
+			return getter.GetCreateAPIPlugin()
+		}
+		return nil
+	}, "API creation", opts.flags())
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeapi
+
+import (
+	"context"
+
+	internalconfig "github.com/seamounts/kubeapi/internal/config"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+)
+
+// InitOptions are the options of InitProject, which match the flags of `kubeapi init`
+type InitOptions struct {
+	// Repo is the go module of the project, guessed from the project directory if empty
+	Repo string
+	// Domain is the domain of the API groups, my.domain if empty
+	Domain string
+	// License is the license of the boilerplate, apache2 (default) or none
+	License string
+	// Owner is the copyright owner of the boilerplate
+	Owner string
+	// KubernetesVersion is the Kubernetes minor version the project targets, e.g. "1.28", none if empty
+	KubernetesVersion string
+	// ClientStyle is the style of the generated clients, legacy (default) or context
+	ClientStyle string
+	// Registration is how the API types are registered in schemes, controller-runtime (default) or apimachinery
+	Registration string
+	// SkipGoVersionCheck skips checking the version of the go toolchain
+	SkipGoVersionCheck bool
+	// TemplatesDir is where the built-in templates are overridden, .kubeapi/templates if empty
+	TemplatesDir string
+
+	// Flags are the values of other flags of the plugins, by name
+	Flags map[string]string
+}
+
+// flags returns the values of the flags of the init subcommands
+func (o InitOptions) flags() map[string][]string {
+	flags := make(map[string][]string, len(o.Flags)+9)
+	for name, value := range o.Flags {
+		flags[name] = []string{value}
+	}
+	setString(flags, "repo", o.Repo)
+	setString(flags, "domain", o.Domain)
+	setString(flags, "license", o.License)
+	setString(flags, "owner", o.Owner)
+	setString(flags, "kubernetes-version", o.KubernetesVersion)
+	setString(flags, "client-style", o.ClientStyle)
+	setString(flags, "registration", o.Registration)
+	setBool(flags, "skip-go-version-check", o.SkipGoVersionCheck, false)
+	setString(flags, "templates-dir", o.TemplatesDir)
+	return flags
+}
+
+// InitProject initializes a new project in dir
+func InitProject(ctx context.Context, dir string, opts InitOptions, options ...Option) (*Result, error) {
+	o, err := newOptions(dir, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := internalconfig.ReadFromFs(o.rt.FS, internalconfig.DefaultPath); err == nil {
+		return nil, internalconfig.AlreadyInitializedError()
+	}
+	cfg := internalconfig.New(internalconfig.DefaultPath)
+	cfg.InjectFS(o.rt.FS)
+	cfg.Layout = o.layout()
+
+	plugins, err := o.resolvePlugins(nil)
+	if err != nil {
+		return nil, err
+	}
+	return run(ctx, o, cfg, plugins, func(p plugin.Base) plugin.GenericSubcommand {
+		if getter, isGetter := p.(plugin.InitPluginGetter); isGetter {
+			return getter.GetInitPlugin()
+		}
+		return nil
+	}, "project initialization", opts.flags())
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kubeapi scaffolds projects and APIs from Go, without the cli:
+//
+//	res, err := kubeapi.InitProject(ctx, "/path/to/project", kubeapi.InitOptions{Repo: "example.com/project"})
+//	...
+//	res, err = kubeapi.CreateAPI(ctx, "/path/to/project", kubeapi.APIOptions{Group: "ship", Version: "v1", Kind: "Frigate"})
+//
+// Like the cli, the functions run the subcommands of plugins, the built-in one by default, and make their changes
+// to the project in a transaction with the saving of the PROJECT file, which is rolled back if any of them fails.
+package kubeapi
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/pflag"
+
+	"github.com/seamounts/kubeapi/internal/chain"
+	"github.com/seamounts/kubeapi/internal/cmdutil"
+	internalconfig "github.com/seamounts/kubeapi/internal/config"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+	pluginv1 "github.com/seamounts/kubeapi/pkg/plugin/v1"
+)
+
+// commandName is the command name given to the plugins, e.g. for the next steps they print
+const commandName = "kubeapi"
+
+// Result describes what a function did to the project
+type Result struct {
+	// Files are the project files that were created, updated or deleted, sorted by path
+	Files []file.Change
+	// Config is the project configuration, as saved in the PROJECT file
+	Config config.Config
+}
+
+// Option configures how the functions run
+type Option func(*options)
+
+type options struct {
+	rt      plugin.Runtime
+	plugins []plugin.Base
+}
+
+// WithRuntime makes the functions run in rt, e.g. to write their output to other writers than the standard
+// ones or to use an in-memory file system. The project root of rt is set to the project directory.
+func WithRuntime(rt plugin.Runtime) Option {
+	return func(o *options) {
+		o.rt = rt
+	}
+}
+
+// WithPlugins makes the functions run the subcommands of the provided plugins, in order, instead of the built-in
+// plugin. Projects initialized with other plugins than the built-in one record them in their layout, and later
+// functions run the plugins of that layout.
+func WithPlugins(plugins ...plugin.Base) Option {
+	return func(o *options) {
+		o.plugins = plugins
+	}
+}
+
+func newOptions(dir string, opts []Option) (options, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	o.rt.ProjectRoot = ""
+	rt, err := o.rt.WithDefaults(dir)
+	if err != nil {
+		return o, err
+	}
+	o.rt = rt
+
+	return o, nil
+}
+
+// layout returns the keys of the plugins to record in the layout of a new project
+func (o options) layout() []string {
+	if len(o.plugins) == 0 {
+		return nil
+	}
+	return chain.Keys(o.plugins)
+}
+
+// resolvePlugins returns the plugins of the layout of a project, or the selected ones if it has none
+func (o options) resolvePlugins(layout []string) ([]plugin.Base, error) {
+	available := o.plugins
+	if len(available) == 0 {
+		available = []plugin.Base{&pluginv1.Plugin{}}
+	}
+	if len(layout) == 0 {
+		return available, nil
+	}
+
+	resolved := make([]plugin.Base, 0, len(layout))
+	for _, key := range layout {
+		p := chain.Lookup(available, key)
+		if p == nil {
+			return nil, fmt.Errorf("plugin %q of the project layout is not available", key)
+		}
+		resolved = append(resolved, p)
+	}
+	return resolved, nil
+}
+
+// run runs the chain of the subcommands of plugins returned by get in a transaction with the saving of cfg.
+// Merge conflicts return the result of the committed changes along with the error.
+func run(ctx context.Context, o options, cfg *internalconfig.Config, plugins []plugin.Base,
+	get func(plugin.Base) plugin.GenericSubcommand, what string, flags map[string][]string) (*Result, error) {
+	ch, err := chain.New(plugins, get, what)
+	if err != nil {
+		return nil, err
+	}
+	ch.InjectConfig(&cfg.Config)
+	ch.InjectPresets(chain.Presets(plugins))
+	fs := pflag.NewFlagSet(what, pflag.ContinueOnError)
+	ch.BindFlags(fs)
+	if err := ch.Err(); err != nil {
+		return nil, err
+	}
+	ch.UpdateContext(&plugin.Context{CommandName: commandName})
+
+	if err := setFlags(fs, flags); err != nil {
+		return nil, err
+	}
+
+	changes, err := cmdutil.RunInTransaction(ctx, o.rt, cfg, ch, fmt.Sprintf("failed to run %s", what))
+	if err != nil && changes == nil {
+		return nil, err
+	}
+	return &Result{Files: changes, Config: cfg.Config}, err
+}
+
+// setFlags sets the flags with the provided names to their values
+func setFlags(fs *pflag.FlagSet, flags map[string][]string) error {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("unknown flag --%s", name)
+		}
+		for _, value := range flags[name] {
+			if err := fs.Set(name, value); err != nil {
+				return fmt.Errorf("invalid value %q for flag --%s: %v", value, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// setString sets the flag name to value if it is not empty
+func setString(flags map[string][]string, name, value string) {
+	if value != "" {
+		flags[name] = []string{value}
+	}
+}
+
+// setStrings sets the values of the flag name, which may be repeated, if there are any
+func setStrings(flags map[string][]string, name string, values []string) {
+	if len(values) != 0 {
+		flags[name] = values
+	}
+}
+
+// setBool sets the flag name to value if it is not the default one
+func setBool(flags map[string][]string, name string, value, defaultValue bool) {
+	if value != defaultValue {
+		flags[name] = []string{strconv.FormatBool(value)}
+	}
+}
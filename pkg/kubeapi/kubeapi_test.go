@@ -0,0 +1,164 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeapi_test
+
+import (
+	"context"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/seamounts/kubeapi/pkg/kubeapi"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+	"github.com/seamounts/kubeapi/pkg/scaffold"
+	"github.com/seamounts/kubeapi/pkg/scaffoldtest"
+)
+
+func TestCreateAPI(t *testing.T) {
+	ctx := context.Background()
+	fs := afero.NewBasePathFs(afero.NewMemMapFs(), scaffoldtest.ProjectRoot)
+	rt := kubeapi.WithRuntime(plugin.Runtime{FS: fs, Stdout: ioutil.Discard, Stderr: ioutil.Discard})
+
+	res, err := kubeapi.InitProject(ctx, scaffoldtest.ProjectRoot, kubeapi.InitOptions{
+		Repo:               "example.com/project",
+		Domain:             "example.com",
+		SkipGoVersionCheck: true,
+	}, rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Config.Repo != "example.com/project" || res.Config.Domain != "example.com" {
+		t.Errorf("unexpected config %+v", res.Config)
+	}
+
+	if _, err := kubeapi.InitProject(ctx, scaffoldtest.ProjectRoot, kubeapi.InitOptions{}, rt); err == nil {
+		t.Error("expected an error when initializing the project again")
+	}
+
+	res, err = kubeapi.CreateAPI(ctx, scaffoldtest.ProjectRoot, kubeapi.APIOptions{
+		Group:        "ship",
+		Version:      "v1beta1",
+		Kind:         "Frigate",
+		SkipGenerate: true,
+	}, rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created []string
+	for _, change := range res.Files {
+		switch {
+		case change.Action == file.Created:
+			created = append(created, change.Path)
+		case change.Path != "PROJECT" || change.Action != file.Updated:
+			t.Errorf("unexpected change %+v", change)
+		}
+	}
+	expected := []string{
+		".kubeapi/base/apis/install/install.go",
+		".kubeapi/base/apis/ship/v1beta1/doc.go",
+		".kubeapi/base/apis/ship/v1beta1/frigate_types.go",
+		".kubeapi/base/apis/ship/v1beta1/register.go",
+		"apis/install/install.go",
+		"apis/ship/v1beta1/doc.go",
+		"apis/ship/v1beta1/frigate_types.go",
+		"apis/ship/v1beta1/register.go",
+	}
+	if !reflect.DeepEqual(created, expected) {
+		t.Errorf("expected the files %v to be created, got %v", expected, created)
+	}
+	if !res.Config.HasResource(config.GVK{Group: "ship", Version: "v1beta1", Kind: "Frigate"}) {
+		t.Errorf("the resource is not tracked in %+v", res.Config.Resources)
+	}
+
+	if _, err := kubeapi.CreateAPI(ctx, scaffoldtest.ProjectRoot, kubeapi.APIOptions{
+		Kind:  "Frigate",
+		Flags: map[string]string{"unknown": "value"},
+	}, rt); err == nil {
+		t.Error("expected an error for an unknown flag")
+	}
+}
+
+func TestCreateAPIMergeConflict(t *testing.T) {
+	ctx := context.Background()
+	fs := afero.NewBasePathFs(afero.NewMemMapFs(), scaffoldtest.ProjectRoot)
+	rt := kubeapi.WithRuntime(plugin.Runtime{FS: fs, Stdout: ioutil.Discard, Stderr: ioutil.Discard})
+
+	if _, err := kubeapi.InitProject(ctx, scaffoldtest.ProjectRoot, kubeapi.InitOptions{
+		Repo:               "example.com/project",
+		SkipGoVersionCheck: true,
+	}, rt); err != nil {
+		t.Fatal(err)
+	}
+	opts := kubeapi.APIOptions{Group: "ship", Version: "v1beta1", Kind: "Frigate", SkipGenerate: true}
+	if _, err := kubeapi.CreateAPI(ctx, scaffoldtest.ProjectRoot, opts, rt); err != nil {
+		t.Fatal(err)
+	}
+
+	// The example field is replaced both by the user and by the new scaffold
+	const path = "apis/ship/v1beta1/frigate_types.go"
+	types, err := afero.ReadFile(fs, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(types), "Foo string `json:\"foo,omitempty\"`",
+		"Replicas int32 `json:\"replicas\"`", 1)
+	if err := afero.WriteFile(fs, path, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts.Force = true
+	opts.SpecFields = []string{"captain:string"}
+	res, err := kubeapi.CreateAPI(ctx, scaffoldtest.ProjectRoot, opts, rt)
+	if !scaffold.IsMergeConflictError(err) {
+		t.Fatalf("expected a merge conflict, got %v", err)
+	}
+	if res == nil || !containsChange(res.Files, file.Change{Path: path, Action: file.Updated}) {
+		t.Errorf("the merged file is not in the result %+v", res)
+	}
+
+	merged, err := afero.ReadFile(fs, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"<<<<<<< ", "Replicas int32", "=======", "Captain string", ">>>>>>> "} {
+		if !strings.Contains(string(merged), expected) {
+			t.Errorf("the merged file does not contain %q:\n%s", expected, merged)
+		}
+	}
+	base, err := afero.ReadFile(fs, ".kubeapi/base/"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(base), "Captain string") {
+		t.Errorf("the base of the merged file was not updated:\n%s", base)
+	}
+}
+
+// containsChange returns true if changes contain the action on the path of change
+func containsChange(changes []file.Change, change file.Change) bool {
+	for _, c := range changes {
+		if c.Path == change.Path && c.Action == change.Action {
+			return true
+		}
+	}
+	return false
+}
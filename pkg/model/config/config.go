@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"gopkg.in/yaml.v2"
+
+	"github.com/seamounts/kubeapi/pkg/model/kubernetes"
 )
 
 // Scaffolding versions
@@ -12,6 +14,24 @@ const (
 	Version1 = "1"
 )
 
+// Styles of the generated clients
+const (
+	// ClientStyleLegacy generates clients whose methods take no context.Context, the default
+	ClientStyleLegacy = "legacy"
+	// ClientStyleContext generates clients whose methods take a context.Context first, and the create,
+	// update and patch options on the mutating calls, as the clients of Kubernetes 1.18 and newer
+	ClientStyleContext = "context"
+)
+
+// Styles of the registration of the API types in schemes
+const (
+	// RegistrationControllerRuntime registers the types with the scheme.Builder of controller-runtime, the default
+	RegistrationControllerRuntime = "controller-runtime"
+	// RegistrationAPIMachinery registers the types with the runtime.SchemeBuilder of apimachinery, for the API
+	// packages and the generated clients not to depend on controller-runtime
+	RegistrationAPIMachinery = "apimachinery"
+)
+
 // Config is the unmarshalled representation of the configuration file
 type Config struct {
 	// Repo is the go package name of the project root
@@ -24,6 +44,32 @@ type Config struct {
 
 	// Domain is the domain associated with the project and used for API groups
 	Domain string `json:"domain,omitempty"`
+
+	// KubernetesVersion is the Kubernetes minor version the project targets, e.g. "1.28", which selects the
+	// versions of the dependencies in go.mod and adapts the generated code
+	KubernetesVersion string `json:"kubernetesVersion,omitempty" yaml:"kubernetesVersion,omitempty"`
+
+	// ClientStyle is the style of the generated clients, ClientStyleLegacy if empty
+	ClientStyle string `json:"clientStyle,omitempty" yaml:"clientStyle,omitempty"`
+
+	// Registration is the style of the registration of the API types in schemes, RegistrationControllerRuntime
+	// if empty
+	Registration string `json:"registration,omitempty" yaml:"registration,omitempty"`
+
+	// Layout are the keys of the plugins that scaffold the project, in the order they run
+	Layout []string `json:"layout,omitempty" yaml:"layout,omitempty"`
+
+	// ExternalClients are the clients generated for the API types of other modules
+	ExternalClients []ExternalClient `json:"externalClients,omitempty" yaml:"externalClients,omitempty"`
+}
+
+// ExternalClient is a client generated for the API types of other modules
+type ExternalClient struct {
+	// InputPackages are the go packages of the API types, e.g. "github.com/acme/apis/foo/v1"
+	InputPackages []string `json:"inputPackages" yaml:"inputPackages"`
+
+	// Output is the directory the client is generated in, relative to the project root
+	Output string `json:"output" yaml:"output"`
 }
 
 // IsV1 returns true if it is a v1 project
@@ -31,6 +77,47 @@ func (c Config) IsV1() bool {
 	return c.Version == Version1
 }
 
+// ContextAwareClients returns true if the generated clients take a context.Context
+func (c Config) ContextAwareClients() bool {
+	return c.ClientStyle == ClientStyleContext
+}
+
+// ValidateClientStyle returns an error if the style of the generated clients is unknown, or if the
+// targeted Kubernetes version does not support it
+func (c Config) ValidateClientStyle() error {
+	switch c.ClientStyle {
+	case "", ClientStyleLegacy:
+		return nil
+	case ClientStyleContext:
+		if c.KubernetesVersion == "" {
+			return fmt.Errorf("%s clients require a Kubernetes version, 1.18 or newer", c.ClientStyle)
+		}
+		release, err := kubernetes.Lookup(c.KubernetesVersion)
+		if err != nil {
+			return err
+		}
+		if !release.ContextAwareClients() {
+			return fmt.Errorf("%s clients require Kubernetes 1.18 or newer, the project targets %s",
+				c.ClientStyle, release.Version)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown client style %q, must be one of %s, %s",
+			c.ClientStyle, ClientStyleLegacy, ClientStyleContext)
+	}
+}
+
+// ValidateRegistration returns an error if the style of the registration of the API types is unknown
+func (c Config) ValidateRegistration() error {
+	switch c.Registration {
+	case "", RegistrationControllerRuntime, RegistrationAPIMachinery:
+		return nil
+	default:
+		return fmt.Errorf("unknown registration %q, must be one of %s, %s",
+			c.Registration, RegistrationControllerRuntime, RegistrationAPIMachinery)
+	}
+}
+
 // Marshal returns the bytes of c.
 func (c Config) Marshal() ([]byte, error) {
 	// Ignore extra fields at first.
@@ -70,15 +157,10 @@ func (c Config) HasResource(target GVK) bool {
 	return false
 }
 
-// AddResource appends the provided resource to the tracked ones
+// AddResource appends the provided resource to the tracked ones, keeping the plural and the scope of the tracked
+// one with the same group, version and kind
 // It returns if the configuration was modified
-// NOTE: in v1 resources are not tracked, so we return false
 func (c *Config) AddResource(gvk GVK) bool {
-	// Short-circuit v1
-	if c.IsV1() {
-		return false
-	}
-
 	// No-op if the resource was already tracked, return false
 	if c.HasResource(gvk) {
 		return false
@@ -89,6 +171,54 @@ func (c *Config) AddResource(gvk GVK) bool {
 	return true
 }
 
+// TrackResource tracks the provided resource, replacing the tracked one with the same group, version and kind
+// It returns if the configuration was modified
+func (c *Config) TrackResource(gvk GVK) bool {
+	for i, r := range c.Resources {
+		if r.isEqualTo(gvk) {
+			if r == gvk {
+				return false
+			}
+			c.Resources[i] = gvk
+			return true
+		}
+	}
+
+	c.Resources = append(c.Resources, gvk)
+	return true
+}
+
+// AddExternalClient tracks the provided client, replacing the tracked one with the same output
+// It returns if the configuration was modified
+func (c *Config) AddExternalClient(client ExternalClient) bool {
+	for i, ec := range c.ExternalClients {
+		if ec.Output == client.Output {
+			if strings.Join(ec.InputPackages, ",") == strings.Join(client.InputPackages, ",") {
+				return false
+			}
+			c.ExternalClients[i] = client
+			return true
+		}
+	}
+
+	c.ExternalClients = append(c.ExternalClients, client)
+	return true
+}
+
+// UpdateResource replaces a tracked resource with the provided one
+// It returns if the configuration was modified
+func (c *Config) UpdateResource(old, gvk GVK) bool {
+	for i, r := range c.Resources {
+		if r.isEqualTo(old) {
+			c.Resources[i] = gvk
+			return true
+		}
+	}
+
+	// No-op if the resource was not tracked, return false
+	return false
+}
+
 // HasGroup returns true if group is already tracked
 func (c Config) HasGroup(group string) bool {
 	// Return true if the target group is found in the tracked resources
@@ -107,6 +237,11 @@ type GVK struct {
 	Group   string `json:"group,omitempty"`
 	Version string `json:"version,omitempty"`
 	Kind    string `json:"kind,omitempty"`
+
+	// Plural is the plural name of the resource, if it is not derived from its kind
+	Plural string `json:"plural,omitempty" yaml:"plural,omitempty"`
+	// Scope is the scope of the resource, "Namespaced" or "Cluster", if it is known
+	Scope string `json:"scope,omitempty" yaml:"scope,omitempty"`
 }
 
 // isEqualTo compares it with another resource
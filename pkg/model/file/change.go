@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"github.com/spf13/afero"
+)
+
+// Action describes what was done with a scaffolded file
+type Action string
+
+const (
+	// Created means that the file did not exist and was written
+	Created Action = "created"
+
+	// Updated means that the existing file was overwritten or merged
+	Updated Action = "updated"
+
+	// Unchanged means that the existing file already had the scaffolded contents
+	Unchanged Action = "unchanged"
+
+	// Skipped means that the existing file was left untouched
+	Skipped Action = "skipped"
+
+	// Deleted means that the existing file was removed
+	Deleted Action = "deleted"
+)
+
+// Change describes what was done with a scaffolded file
+type Change struct {
+	// Path is the file that was scaffolded
+	Path string `json:"path"`
+
+	// Action is what was done with the file
+	Action Action `json:"action"`
+
+	// Reason explains the action, e.g. why the file was skipped
+	Reason string `json:"reason,omitempty"`
+
+	// Previous is the content of the file before it was scaffolded
+	Previous string `json:"-"`
+
+	// Contents is the content of the file after it was scaffolded
+	Contents string `json:"-"`
+}
+
+// Filesystem is where scaffolded files are read from and written to
+type Filesystem struct {
+	// FS is the abstract file system
+	FS afero.Fs
+
+	// Record, if set, is called with every change made to a scaffolded file
+	Record func(Change)
+}
@@ -19,12 +19,32 @@ package file
 import (
 	"strings"
 	"text/template"
+
+	"github.com/gobuffalo/flect"
 )
 
 // DefaultFuncMap returns the default template.FuncMap for rendering the template.
+// It is also available to the templates that override the built-in ones:
+//   - title: "frigate" -> "Frigate"
+//   - lower: "Frigate" -> "frigate"
+//   - plural: "Frigate" -> "Frigates"
+//   - camel: "frigate_spec" -> "frigateSpec"
+//   - snake: "FrigateSpec" -> "frigate_spec"
+//   - kebab: "FrigateSpec" -> "frigate-spec"
+//   - importAlias: "ship.example.com", "v1" -> "shipexamplecomv1"
 func DefaultFuncMap() template.FuncMap {
 	return template.FuncMap{
-		"title": strings.Title,
-		"lower": strings.ToLower,
+		"title":       strings.Title,
+		"lower":       strings.ToLower,
+		"plural":      flect.Pluralize,
+		"camel":       flect.Camelize,
+		"snake":       flect.Underscore,
+		"kebab":       flect.Dasherize,
+		"importAlias": ImportAlias,
 	}
 }
+
+// ImportAlias returns the alias used to import the package of a group-version
+func ImportAlias(group, version string) string {
+	return strings.ToLower(strings.NewReplacer(".", "", "-", "").Replace(group) + version)
+}
@@ -23,12 +23,42 @@ type Template interface {
 	SetTemplateDefaults() error
 }
 
+// Overridable is a template whose body can be overridden by a user-provided one
+type Overridable interface {
+	Template
+	// GetTemplateName returns the name the template body can be overridden with
+	GetTemplateName() string
+}
+
+// Inserter is a file builder that inserts code fragments in marker positions
+type Inserter interface {
+	Builder
+	// SetTemplateDefaults sets the default values for inserters, e.g. their markers
+	SetTemplateDefaults() error
+	// GetMarkers returns the different markers where code fragments will be inserted
+	GetMarkers() []Marker
+	// GetCodeFragments returns a map that binds markers to code fragments
+	GetCodeFragments() CodeFragmentsMap
+}
+
 // HasRepository allows the repository to be used on a template
 type HasRepository interface {
 	// InjectRepository sets the template repository
 	InjectRepository(string)
 }
 
+// HasKubernetesVersion allows the targeted Kubernetes version to be used on a template
+type HasKubernetesVersion interface {
+	// InjectKubernetesVersion sets the template Kubernetes version
+	InjectKubernetesVersion(string)
+}
+
+// HasRegistration allows the registration style of the API types to be used on a template
+type HasRegistration interface {
+	// InjectRegistration sets the template registration style
+	InjectRegistration(string)
+}
+
 // HasResource allows a resource to be used on a template
 type HasResource interface {
 	// InjectResource sets the template resource
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// MarkerPrefix is the prefix of the values of the markers, e.g. to write them in templates
+const MarkerPrefix = "+kubeapi:scaffold:"
+
+var commentsByExt = map[string]string{
+	".go":   "//",
+	".yaml": "#",
+	".yml":  "#",
+}
+
+// Marker represents a comment LoC that will be used to insert code fragments by inserters
+type Marker struct {
+	comment string
+	value   string
+}
+
+// NewMarkerFor creates a new marker customized for the specific file
+// It returns an error if the comments of the file are unknown
+func NewMarkerFor(path string, value string) (Marker, error) {
+	ext := filepath.Ext(path)
+	if comment, found := commentsByExt[ext]; found {
+		return Marker{comment, value}, nil
+	}
+
+	return Marker{}, fmt.Errorf("unknown file extension: '%s', expected '.go', '.yaml' or '.yml'", ext)
+}
+
+// String implements Stringer
+func (m Marker) String() string {
+	return m.comment + " " + MarkerPrefix + m.value
+}
+
+// CodeFragments represents a set of code fragments
+// A code fragment is a number of consecutive lines of code
+type CodeFragments []string
+
+// CodeFragmentsMap binds Markers and CodeFragments together
+type CodeFragmentsMap map[Marker]CodeFragments
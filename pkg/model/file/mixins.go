@@ -1,6 +1,9 @@
 package file
 
-import "github.com/seamounts/kubeapi/pkg/model/resource"
+import (
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+)
 
 // PathMixin provides file builders with a path field
 type PathMixin struct {
@@ -62,6 +65,38 @@ func (m *RepositoryMixin) InjectRepository(repository string) {
 	}
 }
 
+// KubernetesVersionMixin provides templates with a injectable Kubernetes version field
+type KubernetesVersionMixin struct {
+	// KubernetesVersion is the Kubernetes minor version the project targets, empty if it targets none
+	KubernetesVersion string
+}
+
+// InjectKubernetesVersion implements HasKubernetesVersion
+func (m *KubernetesVersionMixin) InjectKubernetesVersion(version string) {
+	if m.KubernetesVersion == "" {
+		m.KubernetesVersion = version
+	}
+}
+
+// RegistrationMixin provides templates with a injectable registration style field
+type RegistrationMixin struct {
+	// Registration is the style of the registration of the API types in schemes, controller-runtime if empty
+	Registration string
+}
+
+// InjectRegistration implements HasRegistration
+func (m *RegistrationMixin) InjectRegistration(registration string) {
+	if m.Registration == "" {
+		m.Registration = registration
+	}
+}
+
+// APIMachineryRegistration returns true if the API types are registered with the runtime.SchemeBuilder of
+// apimachinery instead of the scheme.Builder of controller-runtime
+func (m *RegistrationMixin) APIMachineryRegistration() bool {
+	return m.Registration == config.RegistrationAPIMachinery
+}
+
 // ResourceMixin provides templates with a injectable resource field
 type ResourceMixin struct {
 	Resource *resource.Resource
@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kubernetes holds the compatibility matrix of the Kubernetes versions projects can target,
+// with the versions of the modules their generated code depends on.
+package kubernetes
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Release is a Kubernetes minor version a project can target
+type Release struct {
+	// Version is the minor version, e.g. "1.28"
+	Version string
+	// Minor is the minor number of the version, e.g. 28
+	Minor int
+	// Go is the go version written to go.mod
+	Go string
+	// Libraries is the version of the k8s.io/api, k8s.io/apimachinery and k8s.io/client-go modules,
+	// which are released together
+	Libraries string
+	// ControllerRuntime is the version of the sigs.k8s.io/controller-runtime module
+	ControllerRuntime string
+}
+
+// Module is a module required by the projects that target a release
+type Module struct {
+	Path    string
+	Version string
+}
+
+// Modules returns the modules required by the projects that target r, sorted by path. controller-runtime is
+// only required by the projects that register their API types with it.
+func (r Release) Modules(controllerRuntime bool) []Module {
+	modules := []Module{
+		{Path: "k8s.io/api", Version: r.Libraries},
+		{Path: "k8s.io/apimachinery", Version: r.Libraries},
+		{Path: "k8s.io/client-go", Version: r.Libraries},
+	}
+	if controllerRuntime {
+		modules = append(modules, Module{Path: "sigs.k8s.io/controller-runtime", Version: r.ControllerRuntime})
+	}
+	return modules
+}
+
+// ContextAwareClients returns true if the client-go of r requires a context.Context to make requests,
+// which the clients generated for older releases do not pass
+func (r Release) ContextAwareClients() bool {
+	return r.Minor >= 18
+}
+
+// releases is the compatibility matrix, by minor number
+var releases = map[int]Release{
+	16: {Go: "1.13", Libraries: "v0.16.15", ControllerRuntime: "v0.4.0"},
+	17: {Go: "1.13", Libraries: "v0.17.17", ControllerRuntime: "v0.5.0"},
+	18: {Go: "1.13", Libraries: "v0.18.20", ControllerRuntime: "v0.6.5"},
+	19: {Go: "1.15", Libraries: "v0.19.16", ControllerRuntime: "v0.7.2"},
+	20: {Go: "1.15", Libraries: "v0.20.15", ControllerRuntime: "v0.8.3"},
+	21: {Go: "1.16", Libraries: "v0.21.14", ControllerRuntime: "v0.9.7"},
+	22: {Go: "1.16", Libraries: "v0.22.17", ControllerRuntime: "v0.10.3"},
+	23: {Go: "1.17", Libraries: "v0.23.17", ControllerRuntime: "v0.11.2"},
+	24: {Go: "1.18", Libraries: "v0.24.17", ControllerRuntime: "v0.12.3"},
+	25: {Go: "1.19", Libraries: "v0.25.16", ControllerRuntime: "v0.13.1"},
+	26: {Go: "1.19", Libraries: "v0.26.15", ControllerRuntime: "v0.14.6"},
+	27: {Go: "1.20", Libraries: "v0.27.16", ControllerRuntime: "v0.15.3"},
+	28: {Go: "1.20", Libraries: "v0.28.15", ControllerRuntime: "v0.16.6"},
+	29: {Go: "1.21", Libraries: "v0.29.15", ControllerRuntime: "v0.17.6"},
+	30: {Go: "1.22", Libraries: "v0.30.14", ControllerRuntime: "v0.18.7"},
+}
+
+// Lookup returns the release of version, which may be a minor or a patch version with an optional "v"
+// prefix, e.g. "1.28", "v1.28" or "1.28.3"
+func Lookup(version string) (Release, error) {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "1" {
+		return Release{}, fmt.Errorf("invalid Kubernetes version %q, expected a version like 1.28", version)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return Release{}, fmt.Errorf("invalid Kubernetes version %q, expected a version like 1.28", version)
+	}
+
+	release, found := releases[minor]
+	if !found {
+		return Release{}, fmt.Errorf("unsupported Kubernetes version %q, must be one of %s",
+			version, strings.Join(SupportedVersions(), ", "))
+	}
+	release.Version = fmt.Sprintf("1.%d", minor)
+	release.Minor = minor
+	return release, nil
+}
+
+// SupportedVersions returns the minor versions projects can target, in order
+func SupportedVersions() []string {
+	minors := make([]int, 0, len(releases))
+	for minor := range releases {
+		minors = append(minors, minor)
+	}
+	sort.Ints(minors)
+
+	versions := make([]string, 0, len(minors))
+	for _, minor := range minors {
+		versions = append(versions, fmt.Sprintf("1.%d", minor))
+	}
+	return versions
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// namespacedScope is the scope of the namespaced resources
+const namespacedScope = "Namespaced"
+
+// APISpec is a resource of the files LoadAPIs loads
+type APISpec struct {
+	Group   string `yaml:"group"`
+	Version string `yaml:"version"`
+	Kind    string `yaml:"kind"`
+	// Plural is the plural of the Kind, computed from it if empty
+	Plural string `yaml:"plural,omitempty"`
+	// Scope is Namespaced, the default, or Cluster
+	Scope string `yaml:"scope,omitempty"`
+	// Preset is the name of the preset of the Kind, if any
+	Preset       string       `yaml:"preset,omitempty"`
+	Spec         []Field      `yaml:"spec,omitempty"`
+	Status       []Field      `yaml:"status,omitempty"`
+	NoStatus     bool         `yaml:"noStatus,omitempty"`
+	Subresources Subresources `yaml:"subresources,omitempty"`
+	// Markers are the markers of the Kind, without their "+" prefix
+	Markers []string `yaml:"markers,omitempty"`
+}
+
+// apisFile are the contents of the files LoadAPIs loads
+type apisFile struct {
+	Resources []APISpec `yaml:"resources"`
+}
+
+// LoadAPIs loads the resources of the YAML contents of a file, e.g.
+//
+//	resources:
+//	- group: ship
+//	  version: v1beta1
+//	  kind: Frigate
+//	  plural: frigates
+//	  scope: Namespaced
+//	  spec:
+//	  - name: replicas
+//	    type: int32
+//	  status:
+//	  - name: readyReplicas
+//	    type: int32
+//	  subresources:
+//	    status: true
+//	    scale:
+//	      specReplicasPath: .spec.replicas
+//	      statusReplicasPath: .status.readyReplicas
+//
+// The fields are defined as in the files LoadFields loads. The resources are not validated, and their presets
+// are not applied.
+func LoadAPIs(data []byte) ([]APISpec, error) {
+	var file apisFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid resources: %v", err)
+	}
+	if len(file.Resources) == 0 {
+		return nil, errors.New("no resource is defined")
+	}
+	return file.Resources, nil
+}
+
+// Options returns the options of the resource of s, with a marker for its plural and scope if they are set
+func (s APISpec) Options() (Options, error) {
+	opts := Options{
+		Group:        s.Group,
+		Version:      s.Version,
+		Kind:         s.Kind,
+		Plural:       s.Plural,
+		Namespaced:   true,
+		SpecFields:   s.Spec,
+		StatusFields: s.Status,
+		NoStatus:     s.NoStatus,
+		Subresources: s.Subresources,
+	}
+
+	switch s.Scope {
+	case "", namespacedScope:
+	case clusterScope:
+		opts.Namespaced = false
+	default:
+		return Options{}, fmt.Errorf("unknown scope %q, must be one of %s, %s", s.Scope, namespacedScope, clusterScope)
+	}
+
+	var resource []string
+	if s.Plural != "" {
+		resource = append(resource, "path="+s.Plural)
+	}
+	if s.Scope != "" {
+		resource = append(resource, "scope="+s.Scope)
+	}
+	if len(resource) != 0 {
+		opts.Markers = append(opts.Markers, "kubebuilder:resource:"+strings.Join(resource, ","))
+	}
+	opts.Markers = append(opts.Markers, s.Markers...)
+	return opts, nil
+}
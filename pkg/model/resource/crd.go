@@ -0,0 +1,503 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// crdKind is the kind of the documents LoadCRDs loads
+	crdKind = "CustomResourceDefinition"
+	// clusterScope is the scope of the CustomResourceDefinitions of the resources that are not namespaced
+	clusterScope = "Cluster"
+)
+
+// crd is the part of a CustomResourceDefinition, of apiextensions.k8s.io/v1 or v1beta1, that is imported
+type crd struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Group string `yaml:"group"`
+		Names struct {
+			Kind       string   `yaml:"kind"`
+			Plural     string   `yaml:"plural"`
+			Singular   string   `yaml:"singular"`
+			ShortNames []string `yaml:"shortNames"`
+			Categories []string `yaml:"categories"`
+		} `yaml:"names"`
+		Scope    string       `yaml:"scope"`
+		Versions []crdVersion `yaml:"versions"`
+
+		// The fields of v1beta1 that apply to every version
+		Version                  string             `yaml:"version"`
+		Validation               *crdValidation     `yaml:"validation"`
+		Subresources             *crdSubresources   `yaml:"subresources"`
+		AdditionalPrinterColumns []crdPrinterColumn `yaml:"additionalPrinterColumns"`
+	} `yaml:"spec"`
+}
+
+type crdVersion struct {
+	Name                     string             `yaml:"name"`
+	Served                   bool               `yaml:"served"`
+	Storage                  bool               `yaml:"storage"`
+	Schema                   *crdValidation     `yaml:"schema"`
+	Subresources             *crdSubresources   `yaml:"subresources"`
+	AdditionalPrinterColumns []crdPrinterColumn `yaml:"additionalPrinterColumns"`
+}
+
+type crdValidation struct {
+	OpenAPIV3Schema *crdSchema `yaml:"openAPIV3Schema"`
+}
+
+type crdSubresources struct {
+	Status *struct{}         `yaml:"status"`
+	Scale  *ScaleSubresource `yaml:"scale"`
+}
+
+type crdPrinterColumn struct {
+	Name        string `yaml:"name"`
+	Type        string `yaml:"type"`
+	Format      string `yaml:"format"`
+	Description string `yaml:"description"`
+	Priority    int    `yaml:"priority"`
+	JSONPath    string `yaml:"jsonPath"`
+	// JSONPathV1beta1 is the JSON path of the columns of v1beta1
+	JSONPathV1beta1 string `yaml:"JSONPath"`
+}
+
+// crdSchema is the part of an OpenAPI v3 schema of a CustomResourceDefinition that is imported
+type crdSchema struct {
+	Type                 string                   `yaml:"type"`
+	Format               string                   `yaml:"format"`
+	Description          string                   `yaml:"description"`
+	Properties           crdProperties            `yaml:"properties"`
+	Required             []string                 `yaml:"required"`
+	Items                *crdSchema               `yaml:"items"`
+	AdditionalProperties *crdAdditionalProperties `yaml:"additionalProperties"`
+	Nullable             bool                     `yaml:"nullable"`
+	Enum                 []interface{}            `yaml:"enum"`
+	Default              interface{}              `yaml:"default"`
+	Pattern              string                   `yaml:"pattern"`
+	Minimum              *float64                 `yaml:"minimum"`
+	Maximum              *float64                 `yaml:"maximum"`
+	ExclusiveMinimum     bool                     `yaml:"exclusiveMinimum"`
+	ExclusiveMaximum     bool                     `yaml:"exclusiveMaximum"`
+	MinLength            *int64                   `yaml:"minLength"`
+	MaxLength            *int64                   `yaml:"maxLength"`
+	MinItems             *int64                   `yaml:"minItems"`
+	MaxItems             *int64                   `yaml:"maxItems"`
+
+	IntOrString           bool     `yaml:"x-kubernetes-int-or-string"`
+	PreserveUnknownFields bool     `yaml:"x-kubernetes-preserve-unknown-fields"`
+	EmbeddedResource      bool     `yaml:"x-kubernetes-embedded-resource"`
+	ListType              string   `yaml:"x-kubernetes-list-type"`
+	ListMapKeys           []string `yaml:"x-kubernetes-list-map-keys"`
+	MapType               string   `yaml:"x-kubernetes-map-type"`
+}
+
+// crdProperties are the properties of an object schema, in the order they are defined in
+type crdProperties []crdProperty
+
+type crdProperty struct {
+	Name   string
+	Schema crdSchema
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, keeping the order of the properties
+func (p *crdProperties) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var items yaml.MapSlice
+	if err := unmarshal(&items); err != nil {
+		return err
+	}
+	for _, item := range items {
+		data, err := yaml.Marshal(item.Value)
+		if err != nil {
+			return err
+		}
+		property := crdProperty{Name: fmt.Sprint(item.Key)}
+		if err := yaml.Unmarshal(data, &property.Schema); err != nil {
+			return fmt.Errorf("invalid schema of property %s: %v", property.Name, err)
+		}
+		*p = append(*p, property)
+	}
+	return nil
+}
+
+// crdAdditionalProperties is either a boolean or the schema of the values of a map
+type crdAdditionalProperties struct {
+	Allowed bool
+	Schema  *crdSchema
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (a *crdAdditionalProperties) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&a.Allowed); err == nil {
+		return nil
+	}
+	a.Allowed = true
+	return unmarshal(&a.Schema)
+}
+
+// LoadCRDs returns the options of the resources of the served versions of the CustomResourceDefinitions in the
+// YAML documents of data, of apiextensions.k8s.io/v1 or v1beta1. Their group is split into the Group and the
+// Domain of the options, and the fields of their spec and of their status are converted from their OpenAPI v3
+// schemas, with the markers of their validations.
+func LoadCRDs(data []byte) ([]Options, error) {
+	var result []Options
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for i := 1; ; i++ {
+		var c crd
+		if err := decoder.Decode(&c); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid document %d: %v", i, err)
+		}
+		if c.Kind == "" {
+			// Empty document
+			continue
+		}
+		if c.Kind != crdKind {
+			return nil, fmt.Errorf("document %d is a %s, not a %s", i, c.Kind, crdKind)
+		}
+
+		opts, err := c.options()
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %v", crdKind, c.Metadata.Name, err)
+		}
+		result = append(result, opts...)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no served version of a %s found", crdKind)
+	}
+	return result, nil
+}
+
+// options returns the options of the resources of the served versions of c
+func (c crd) options() ([]Options, error) {
+	group, domain := c.Spec.Group, ""
+	if i := strings.Index(group, "."); i != -1 {
+		group, domain = group[:i], group[i+1:]
+	}
+
+	versions := c.Spec.Versions
+	if len(versions) == 0 && c.Spec.Version != "" {
+		versions = []crdVersion{{Name: c.Spec.Version, Served: true, Storage: true}}
+	}
+
+	var result []Options
+	for _, v := range versions {
+		if !v.Served {
+			continue
+		}
+		opts := Options{
+			Group:      group,
+			Domain:     domain,
+			Version:    v.Name,
+			Kind:       c.Spec.Names.Kind,
+			Plural:     c.Spec.Names.Plural,
+			Namespaced: c.Spec.Scope != clusterScope,
+			Markers:    c.markers(v, len(versions) > 1),
+		}
+
+		validation, subresources := v.Schema, v.Subresources
+		if validation == nil {
+			validation = c.Spec.Validation
+		}
+		if subresources == nil {
+			subresources = c.Spec.Subresources
+		}
+		if subresources != nil {
+			opts.Subresources = Subresources{Status: subresources.Status != nil, Scale: subresources.Scale}
+		}
+
+		if validation != nil && validation.OpenAPIV3Schema != nil {
+			if err := opts.setFields(*validation.OpenAPIV3Schema); err != nil {
+				return nil, fmt.Errorf("version %s: %v", v.Name, err)
+			}
+		}
+		result = append(result, opts)
+	}
+	return result, nil
+}
+
+// markers returns the markers of the Kind of version v of c, including the storage version one if c has several
+// versions
+func (c crd) markers(v crdVersion, multipleVersions bool) []string {
+	resource := []string{"path=" + c.Spec.Names.Plural, "scope=" + c.Spec.Scope}
+	if c.Spec.Scope == "" {
+		resource[1] = "scope=Namespaced"
+	}
+	if c.Spec.Names.Singular != "" {
+		resource = append(resource, "singular="+c.Spec.Names.Singular)
+	}
+	if len(c.Spec.Names.ShortNames) != 0 {
+		resource = append(resource, "shortName="+strings.Join(c.Spec.Names.ShortNames, ";"))
+	}
+	if len(c.Spec.Names.Categories) != 0 {
+		resource = append(resource, "categories="+strings.Join(c.Spec.Names.Categories, ";"))
+	}
+	markers := []string{"kubebuilder:resource:" + strings.Join(resource, ",")}
+	if multipleVersions && v.Storage {
+		markers = append(markers, "kubebuilder:storageversion")
+	}
+
+	columns := v.AdditionalPrinterColumns
+	if len(columns) == 0 {
+		columns = c.Spec.AdditionalPrinterColumns
+	}
+	for _, column := range columns {
+		path := column.JSONPath
+		if path == "" {
+			path = column.JSONPathV1beta1
+		}
+		marker := fmt.Sprintf("kubebuilder:printcolumn:name=%q,type=%s,JSONPath=%q", column.Name, column.Type, path)
+		if column.Format != "" {
+			marker += ",format=" + column.Format
+		}
+		if column.Priority != 0 {
+			marker += ",priority=" + strconv.Itoa(column.Priority)
+		}
+		if column.Description != "" {
+			marker += fmt.Sprintf(",description=%q", column.Description)
+		}
+		markers = append(markers, marker)
+	}
+	return markers
+}
+
+// setFields sets the fields of the spec and of the status of opts to the ones of the schema of the Kind
+func (opts *Options) setFields(schema crdSchema) error {
+	hasStatus := false
+	for _, property := range schema.Properties {
+		switch property.Name {
+		case "apiVersion", "kind", "metadata":
+		case "spec", "status":
+			fields, err := schemaFields(property.Schema)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v", property.Name, err)
+			}
+			if property.Name == "spec" {
+				opts.SpecFields = fields
+			} else {
+				opts.StatusFields, hasStatus = fields, true
+			}
+		default:
+			return fmt.Errorf("unsupported property %s, only spec and status are imported", property.Name)
+		}
+	}
+	opts.NoStatus = !hasStatus && !opts.Subresources.Status
+	return nil
+}
+
+// schemaFields returns the fields of the properties of the object schema
+func schemaFields(schema crdSchema) ([]Field, error) {
+	required := make(map[string]struct{}, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = struct{}{}
+	}
+
+	fields := make([]Field, 0, len(schema.Properties))
+	for _, property := range schema.Properties {
+		f, err := schemaField(property.Name, property.Schema)
+		if err != nil {
+			return nil, err
+		}
+		_, f.Required = required[property.Name]
+		f.Optional = !f.Required && property.Schema.Nullable
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// schemaField returns the field named name of the schema
+func schemaField(name string, schema crdSchema) (Field, error) {
+	f := Field{Name: name, Doc: schema.Description}
+	if !fieldNameRegex.MatchString(name) {
+		return f, fmt.Errorf("unsupported property name %q, must match %s", name, fieldNameRegex)
+	}
+
+	typ, err := schemaType(&f, schema)
+	if err != nil {
+		return f, fmt.Errorf("invalid property %s: %v", name, err)
+	}
+	f.Type = typ
+	f.Markers = append(f.Markers, schemaMarkers(schema)...)
+	return f, nil
+}
+
+// schemaType returns the type of the field f of the schema, setting the fields of its nested struct if any
+func schemaType(f *Field, schema crdSchema) (string, error) {
+	if schema.IntOrString || schema.Format == "int-or-string" {
+		return "intstr.IntOrString", nil
+	}
+
+	switch schema.Type {
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			return "metav1.Time", nil
+		case "byte":
+			return "[]byte", nil
+		}
+		return "string", nil
+	case "integer":
+		if schema.Format == "int32" {
+			return "int32", nil
+		}
+		return "int64", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		if schema.Items == nil {
+			return "", fmt.Errorf("array without items")
+		}
+		itemType, err := schemaType(f, *schema.Items)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + itemType, nil
+	case "object", "":
+		switch {
+		case schema.EmbeddedResource:
+			f.Markers = append(f.Markers, "kubebuilder:validation:EmbeddedResource")
+			return "runtime.RawExtension", nil
+		case len(schema.Properties) != 0:
+			fields, err := schemaFields(schema)
+			if err != nil {
+				return "", err
+			}
+			f.Fields = fields
+			return ObjectType, nil
+		case schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil:
+			valueType, err := schemaType(f, *schema.AdditionalProperties.Schema)
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + valueType, nil
+		case schema.Type == "object" && schema.AdditionalProperties == nil && !schema.PreserveUnknownFields:
+			// An object without any property
+			return "map[string]string", nil
+		}
+		f.Markers = append(f.Markers, "kubebuilder:pruning:PreserveUnknownFields")
+		return "runtime.RawExtension", nil
+	}
+	return "", fmt.Errorf("unsupported type %q", schema.Type)
+}
+
+// schemaMarkers returns the markers of the validations of the schema
+func schemaMarkers(schema crdSchema) []string {
+	var markers []string
+	add := func(format string, args ...interface{}) {
+		markers = append(markers, fmt.Sprintf(format, args...))
+	}
+
+	switch schema.Format {
+	case "", "int32", "int64", "date-time", "byte", "int-or-string", "double", "float":
+	default:
+		add("kubebuilder:validation:Format=%s", schema.Format)
+	}
+	if len(schema.Enum) != 0 {
+		values := make([]string, 0, len(schema.Enum))
+		for _, value := range schema.Enum {
+			values = append(values, fmt.Sprint(value))
+		}
+		add("kubebuilder:validation:Enum=%s", strings.Join(values, ";"))
+	}
+	if schema.Minimum != nil {
+		add("kubebuilder:validation:Minimum=%s", strconv.FormatFloat(*schema.Minimum, 'f', -1, 64))
+	}
+	if schema.ExclusiveMinimum {
+		add("kubebuilder:validation:ExclusiveMinimum=true")
+	}
+	if schema.Maximum != nil {
+		add("kubebuilder:validation:Maximum=%s", strconv.FormatFloat(*schema.Maximum, 'f', -1, 64))
+	}
+	if schema.ExclusiveMaximum {
+		add("kubebuilder:validation:ExclusiveMaximum=true")
+	}
+	for _, limit := range []struct {
+		marker string
+		value  *int64
+	}{
+		{"MinLength", schema.MinLength},
+		{"MaxLength", schema.MaxLength},
+		{"MinItems", schema.MinItems},
+		{"MaxItems", schema.MaxItems},
+	} {
+		if limit.value != nil {
+			add("kubebuilder:validation:%s=%d", limit.marker, *limit.value)
+		}
+	}
+	if schema.Pattern != "" {
+		add("kubebuilder:validation:Pattern=`%s`", schema.Pattern)
+	}
+	if schema.Default != nil {
+		add("kubebuilder:default=%s", defaultValue(schema.Default))
+	}
+	if schema.ListType != "" {
+		add("listType=%s", schema.ListType)
+	}
+	for _, key := range schema.ListMapKeys {
+		add("listMapKey=%s", key)
+	}
+	if schema.MapType != "" {
+		add("mapType=%s", schema.MapType)
+	}
+	return markers
+}
+
+// defaultValue returns the value of a default marker, the JSON of value unless it is a string
+func defaultValue(value interface{}) string {
+	if s, isString := value.(string); isString {
+		return s
+	}
+	data, err := json.Marshal(jsonValue(value))
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// jsonValue returns value with the maps yaml.v2 decodes, which have interface{} keys, converted to maps with
+// string keys, which can be marshaled to JSON
+func jsonValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			m[fmt.Sprint(k)] = jsonValue(v)
+		}
+		return m
+	case []interface{}:
+		for i, v := range value {
+			value[i] = jsonValue(v)
+		}
+	}
+	return value
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gobuffalo/flect"
+	"gopkg.in/yaml.v2"
+)
+
+// ObjectType is the base type of the fields whose type is a nested struct of their own fields
+const ObjectType = "object"
+
+// TypePackages are the import paths of the packages whose types fields may reference, by the name they are
+// imported with, e.g. "corev1.PodTemplateSpec"
+var TypePackages = map[string]string{
+	"corev1":   "k8s.io/api/core/v1",
+	"intstr":   "k8s.io/apimachinery/pkg/util/intstr",
+	"metav1":   "k8s.io/apimachinery/pkg/apis/meta/v1",
+	"resource": "k8s.io/apimachinery/pkg/api/resource",
+	"runtime":  "k8s.io/apimachinery/pkg/runtime",
+}
+
+var (
+	fieldNameRegex = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+	identRegex     = regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`)
+
+	builtinTypes = map[string]struct{}{
+		"bool": {}, "byte": {}, "string": {},
+		"int": {}, "int8": {}, "int16": {}, "int32": {}, "int64": {},
+		"uint": {}, "uint8": {}, "uint16": {}, "uint32": {}, "uint64": {},
+		"float32": {}, "float64": {},
+	}
+
+	// markerOptions are the markers of the validation options of ParseField, by option name
+	markerOptions = map[string]string{
+		"default":   "kubebuilder:default=%s",
+		"enum":      "kubebuilder:validation:Enum=%s",
+		"max":       "kubebuilder:validation:Maximum=%s",
+		"maxLength": "kubebuilder:validation:MaxLength=%s",
+		"maxItems":  "kubebuilder:validation:MaxItems=%s",
+		"min":       "kubebuilder:validation:Minimum=%s",
+		"minLength": "kubebuilder:validation:MinLength=%s",
+		"minItems":  "kubebuilder:validation:MinItems=%s",
+		"pattern":   "kubebuilder:validation:Pattern=`%s`",
+	}
+)
+
+// Field is a field of the spec or of the status of a resource
+type Field struct {
+	// Name is the JSON name of the field, e.g. "readyReplicas"
+	Name string `json:"name" yaml:"name"`
+	// Type is the Go type of the field, e.g. "int32", "[]string", "map[string]string" or "corev1.PodTemplateSpec",
+	// where "object" stands for the nested struct of Fields, e.g. "[]object"
+	Type string `json:"type" yaml:"type"`
+	// Required is true if the field must be set
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+	// Optional is true if the field may be unset, which is then told apart from its zero value by a pointer
+	Optional bool `json:"optional,omitempty" yaml:"optional,omitempty"`
+	// Doc is the doc comment of the field
+	Doc string `json:"doc,omitempty" yaml:"doc,omitempty"`
+	// Markers are the markers of the field without their "+" prefix, e.g. "kubebuilder:validation:Minimum=0"
+	Markers []string `json:"markers,omitempty" yaml:"markers,omitempty"`
+	// Fields are the fields of the nested struct of the fields of the object type
+	Fields []Field `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// ParseField parses a field from a "name:type[:options]" string, where options are separated by commas and are
+// either "required", "optional", or validations such as "min=0", "max=10", "minLength=1", "maxLength=63",
+// "minItems=1", "maxItems=10", "pattern=^[a-z]+$", "enum=Always;Never" and "default=1".
+// Nested structs can only be defined in files, see LoadFields.
+func ParseField(s string) (Field, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) < 2 {
+		return Field{}, fmt.Errorf("invalid field %q, expected name:type[:options]", s)
+	}
+
+	f := Field{Name: parts[0], Type: parts[1]}
+	if len(parts) == 3 {
+		for _, option := range strings.Split(parts[2], ",") {
+			name, value := option, ""
+			if i := strings.Index(option, "="); i != -1 {
+				name, value = option[:i], option[i+1:]
+			}
+			switch marker, isMarker := markerOptions[name]; {
+			case option == "required":
+				f.Required = true
+			case option == "optional":
+				f.Optional = true
+			case isMarker && value != "":
+				f.Markers = append(f.Markers, fmt.Sprintf(marker, value))
+			default:
+				return Field{}, fmt.Errorf("invalid option %q of field %s", option, f.Name)
+			}
+		}
+	}
+
+	if err := f.Validate(); err != nil {
+		return Field{}, err
+	}
+	return f, nil
+}
+
+// fieldsFile are the contents of the files LoadFields loads
+type fieldsFile struct {
+	Spec   []Field `yaml:"spec,omitempty"`
+	Status []Field `yaml:"status,omitempty"`
+}
+
+// LoadFields loads the spec and the status fields from the YAML contents of a file, e.g.
+//
+//	spec:
+//	- name: replicas
+//	  type: int32
+//	  required: true
+//	  doc: Replicas is the number of desired pods
+//	  markers:
+//	  - kubebuilder:validation:Minimum=0
+//	- name: ports
+//	  type: '[]object'
+//	  fields:
+//	  - name: port
+//	    type: int32
+//	status:
+//	- name: readyReplicas
+//	  type: int32
+func LoadFields(data []byte) (spec, status []Field, err error) {
+	var file fieldsFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("invalid fields: %v", err)
+	}
+	if err := ValidateFields(file.Spec); err != nil {
+		return nil, nil, err
+	}
+	if err := ValidateFields(file.Status); err != nil {
+		return nil, nil, err
+	}
+	return file.Spec, file.Status, nil
+}
+
+// ValidateFields returns an error if a field is invalid or if two fields have the same name
+func ValidateFields(fields []Field) error {
+	names := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+		if _, found := names[f.GoName()]; found {
+			return fmt.Errorf("field %s is defined twice", f.Name)
+		}
+		names[f.GoName()] = struct{}{}
+	}
+	return nil
+}
+
+// Validate returns an error if the name, the type or the nested fields of f are invalid
+func (f Field) Validate() error {
+	if !fieldNameRegex.MatchString(f.Name) {
+		return fmt.Errorf("invalid field name %q, must match %s", f.Name, fieldNameRegex)
+	}
+	if f.Required && f.Optional {
+		return fmt.Errorf("field %s cannot be both required and optional", f.Name)
+	}
+
+	base := f.baseType()
+	switch _, isBuiltin := builtinTypes[base]; {
+	case base == ObjectType:
+		if len(f.Fields) == 0 {
+			return fmt.Errorf("field %s of type %s has no fields", f.Name, f.Type)
+		}
+		if err := ValidateFields(f.Fields); err != nil {
+			return fmt.Errorf("invalid field %s: %v", f.Name, err)
+		}
+		return nil
+	case isBuiltin, identRegex.MatchString(base):
+	case strings.Contains(base, "."):
+		pkg := strings.SplitN(base, ".", 2)
+		if _, found := TypePackages[pkg[0]]; !found || !identRegex.MatchString(pkg[1]) {
+			return fmt.Errorf("invalid type %s of field %s, the types of other packages must be qualified with one of %s",
+				f.Type, f.Name, strings.Join(typePackageNames(), ", "))
+		}
+	default:
+		return fmt.Errorf("invalid type %s of field %s", f.Type, f.Name)
+	}
+	if len(f.Fields) != 0 {
+		return fmt.Errorf("field %s has fields but its type %s is not %s", f.Name, f.Type, ObjectType)
+	}
+	return nil
+}
+
+// GoName returns the name of the Go field of f, e.g. "ReadyReplicas"
+func (f Field) GoName() string {
+	return flect.Pascalize(f.Name)
+}
+
+// ObjectName returns the name of the nested struct of f, which is named after its parent struct and its
+// singular name, e.g. "FrigatePort" for the ports field of the Frigate struct
+func (f Field) ObjectName(parent string) string {
+	name := f.GoName()
+	if f.Type != ObjectType && f.Type != "*"+ObjectType {
+		name = flect.Singularize(name)
+	}
+	return parent + name
+}
+
+// GoType returns the Go type of f, where the object type is the nested struct named objectName. Optional fields
+// whose type is neither a slice, a map nor a pointer are pointers.
+func (f Field) GoType(objectName string) string {
+	t := f.Type
+	if f.baseType() == ObjectType {
+		t = strings.TrimSuffix(t, ObjectType) + objectName
+	}
+	if f.Optional && !strings.HasPrefix(t, "[]") && !strings.HasPrefix(t, "map[") && !strings.HasPrefix(t, "*") {
+		t = "*" + t
+	}
+	return t
+}
+
+// Packages returns the names of the packages the types of f and of its nested fields are qualified with, sorted
+func (f Field) Packages() []string {
+	packages := make(map[string]struct{})
+	f.addPackages(packages)
+
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (f Field) addPackages(packages map[string]struct{}) {
+	if base := f.baseType(); strings.Contains(base, ".") {
+		packages[strings.SplitN(base, ".", 2)[0]] = struct{}{}
+	}
+	for _, nested := range f.Fields {
+		nested.addPackages(packages)
+	}
+}
+
+// baseType returns the type of f without the slice, map and pointer prefixes, e.g. "string" for "map[string]string"
+func (f Field) baseType() string {
+	t := f.Type
+	for {
+		switch {
+		case strings.HasPrefix(t, "[]"):
+			t = t[len("[]"):]
+		case strings.HasPrefix(t, "map[string]"):
+			t = t[len("map[string]"):]
+		case strings.HasPrefix(t, "*"):
+			t = t[len("*"):]
+		default:
+			return t
+		}
+	}
+}
+
+func typePackageNames() []string {
+	names := make([]string, 0, len(TypePackages))
+	for name := range TypePackages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
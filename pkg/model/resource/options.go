@@ -66,6 +66,34 @@ type Options struct {
 
 	// Namespaced is true if the resource is namespaced.
 	Namespaced bool
+	// Domain is the domain of the Group, e.g. "coreos.com" for the monitoring.coreos.com group of an imported
+	// resource, the one of the project if empty.
+	// Optional
+	Domain string
+
+	// SpecFields are the fields of the spec of the Kind, a placeholder field if empty.
+	// Optional
+	SpecFields []Field
+
+	// StatusFields are the fields of the status of the Kind.
+	// Optional
+	StatusFields []Field
+
+	// Preset is the name of the preset of the Kind, see ApplyPreset.
+	// Optional
+	Preset string
+
+	// NoStatus is true if the Kind has no status.
+	// Optional
+	NoStatus bool
+
+	// Subresources are the subresources of the Kind.
+	// Optional
+	Subresources Subresources
+
+	// Markers are the markers of the Kind, without their "+" prefix.
+	// Optional
+	Markers []string
 }
 
 // Validate verifies that all the fields have valid values
@@ -117,7 +145,24 @@ func (opts *Options) Validate() error {
 		return fmt.Errorf("invalid Kind: %#v", validationErrors)
 	}
 
-	// TODO: validate plural strings if provided
+	if err := ValidateFields(opts.SpecFields); err != nil {
+		return fmt.Errorf("invalid spec fields: %v", err)
+	}
+	if err := ValidateFields(opts.StatusFields); err != nil {
+		return fmt.Errorf("invalid status fields: %v", err)
+	}
+	if opts.NoStatus && len(opts.StatusFields) != 0 {
+		return fmt.Errorf("invalid status fields: the Kind has no status")
+	}
+	if err := opts.Subresources.Validate(opts.NoStatus); err != nil {
+		return fmt.Errorf("invalid subresources: %v", err)
+	}
+
+	if opts.Plural != "" {
+		if err := validation.IsDNS1035Label(opts.Plural); err != nil {
+			return fmt.Errorf("plural is invalid: (%v)", err)
+		}
+	}
 
 	return nil
 }
@@ -149,7 +194,9 @@ func (opts *Options) NewResource(c *config.Config) *Resource {
 	pkg := replacer.Replace(path.Join(c.Repo, "apis", "%[group]", "%[version]"))
 
 	domain := c.Domain
-	if !c.HasResource(opts.GVK()) {
+	if opts.Domain != "" {
+		domain = opts.Domain
+	} else if !c.HasResource(opts.GVK()) {
 		if coreDomain, found := coreGroups[opts.Group]; found {
 			pkg = replacer.Replace(path.Join("k8s.io", "api", "%[group]", "%[version]"))
 			domain = coreDomain
@@ -180,5 +227,11 @@ func (opts *Options) newResource() *Resource {
 		Kind:             opts.Kind,
 		Plural:           plural,
 		ImportAlias:      opts.safeImport(opts.Group + opts.Version),
+		SpecFields:       opts.SpecFields,
+		StatusFields:     opts.StatusFields,
+		Preset:           opts.Preset,
+		NoStatus:         opts.NoStatus,
+		Subresources:     opts.Subresources,
+		Markers:          opts.Markers,
 	}
 }
@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/seamounts/kubeapi/pkg/model/kubernetes"
+)
+
+// Preset is a common shape of Kinds, e.g. a workload with replicas and a pod template
+type Preset struct {
+	// Name is the name the preset is selected with, e.g. "workload"
+	Name string
+	// Description is a one-line description of the Kinds of the preset
+	Description string
+	// SpecFields and StatusFields are the fields of the spec and of the status of the Kinds, which come before
+	// the ones set by users
+	SpecFields, StatusFields []Field
+	// NoStatus is true if the Kinds have no status
+	NoStatus bool
+	// Subresources are the subresources of the Kinds
+	Subresources Subresources
+	// Markers are the markers of the Kinds, without their "+" prefix, e.g. "kubebuilder:resource:scope=Cluster"
+	Markers []string
+	// MinKubernetesVersion is the oldest Kubernetes version whose API types the fields may reference, if any
+	MinKubernetesVersion string
+}
+
+// ValidateKubernetesVersion returns an error if the projects that target the Kubernetes version cannot
+// scaffold Kinds of p. Projects that target no version can.
+func (p Preset) ValidateKubernetesVersion(version string) error {
+	if version == "" || p.MinKubernetesVersion == "" {
+		return nil
+	}
+	release, err := kubernetes.Lookup(version)
+	if err != nil {
+		return err
+	}
+	min, err := kubernetes.Lookup(p.MinKubernetesVersion)
+	if err != nil {
+		return err
+	}
+	if release.Minor < min.Minor {
+		return fmt.Errorf("preset %s requires Kubernetes %s or newer, the project targets %s",
+			p.Name, min.Version, release.Version)
+	}
+	return nil
+}
+
+// Subresources are the subresources of a Kind
+type Subresources struct {
+	// Status is true if the Kind has the status subresource
+	Status bool `json:"status,omitempty" yaml:"status,omitempty"`
+	// Scale is the scale subresource of the Kind, if it has one
+	Scale *ScaleSubresource `json:"scale,omitempty" yaml:"scale,omitempty"`
+}
+
+// ScaleSubresource is the scale subresource of a Kind
+type ScaleSubresource struct {
+	// SpecReplicasPath is the JSON path of the desired replicas, e.g. ".spec.replicas"
+	SpecReplicasPath string `json:"specReplicasPath" yaml:"specReplicasPath"`
+	// StatusReplicasPath is the JSON path of the observed replicas, e.g. ".status.replicas"
+	StatusReplicasPath string `json:"statusReplicasPath" yaml:"statusReplicasPath"`
+	// LabelSelectorPath is the JSON path of the serialized label selector of the replicas, e.g. ".status.selector"
+	LabelSelectorPath string `json:"labelSelectorPath,omitempty" yaml:"labelSelectorPath,omitempty"`
+}
+
+// Markers returns the kubebuilder markers of s, without their "+" prefix
+func (s Subresources) Markers() []string {
+	var markers []string
+	if s.Status {
+		markers = append(markers, "kubebuilder:subresource:status")
+	}
+	if s.Scale != nil {
+		marker := fmt.Sprintf("kubebuilder:subresource:scale:specpath=%s,statuspath=%s",
+			s.Scale.SpecReplicasPath, s.Scale.StatusReplicasPath)
+		if s.Scale.LabelSelectorPath != "" {
+			marker += ",selectorpath=" + s.Scale.LabelSelectorPath
+		}
+		markers = append(markers, marker)
+	}
+	return markers
+}
+
+// Validate returns an error if s cannot be the subresources of a Kind, which has no status if noStatus is true
+func (s Subresources) Validate(noStatus bool) error {
+	if noStatus && s.Status {
+		return fmt.Errorf("the status subresource requires a status")
+	}
+	if s.Scale != nil && (s.Scale.SpecReplicasPath == "" || s.Scale.StatusReplicasPath == "") {
+		return fmt.Errorf("the scale subresource requires the paths of the spec and of the status replicas")
+	}
+	return nil
+}
+
+// ApplyPreset makes opts define a Kind of preset p, whose fields come before the ones already set
+func (opts *Options) ApplyPreset(p Preset) {
+	opts.Preset = p.Name
+	opts.SpecFields = append(append([]Field{}, p.SpecFields...), opts.SpecFields...)
+	opts.StatusFields = append(append([]Field{}, p.StatusFields...), opts.StatusFields...)
+	opts.NoStatus = opts.NoStatus || p.NoStatus
+	if p.Subresources.Status {
+		opts.Subresources.Status = true
+	}
+	if p.Subresources.Scale != nil {
+		opts.Subresources.Scale = p.Subresources.Scale
+	}
+	opts.Markers = append(append([]string{}, p.Markers...), opts.Markers...)
+}
+
+// FindPreset returns the preset named name among presets, the first one if several have that name
+func FindPreset(presets []Preset, name string) (Preset, error) {
+	names := make([]string, 0, len(presets))
+	for _, p := range presets {
+		if p.Name == name {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	return Preset{}, fmt.Errorf("unknown preset %q, must be one of %s", name, strings.Join(names, ", "))
+}
@@ -51,6 +51,24 @@ type Resource struct {
 
 	// Namespaced is true if the resource is namespaced.
 	Namespaced bool `json:"namespaced,omitempty"`
+
+	// SpecFields are the fields of the spec of the Kind, a placeholder field if empty.
+	SpecFields []Field `json:"specFields,omitempty"`
+
+	// StatusFields are the fields of the status of the Kind.
+	StatusFields []Field `json:"statusFields,omitempty"`
+
+	// Preset is the name of the preset of the Kind, if any.
+	Preset string `json:"preset,omitempty"`
+
+	// NoStatus is true if the Kind has no status.
+	NoStatus bool `json:"noStatus,omitempty"`
+
+	// Subresources are the subresources of the Kind.
+	Subresources Subresources `json:"subresources,omitempty"`
+
+	// Markers are the markers of the Kind, without their "+" prefix.
+	Markers []string `json:"markers,omitempty"`
 }
 
 // GVK returns the group-version-kind information to check against tracked resources in the configuration file
@@ -80,6 +80,12 @@ func (u Universe) InjectInto(builder file.Builder) {
 		if builderWithRepository, hasRepository := builder.(file.HasRepository); hasRepository {
 			builderWithRepository.InjectRepository(u.Config.Repo)
 		}
+		if builderWithKubernetesVersion, hasKubernetesVersion := builder.(file.HasKubernetesVersion); hasKubernetesVersion {
+			builderWithKubernetesVersion.InjectKubernetesVersion(u.Config.KubernetesVersion)
+		}
+		if builderWithRegistration, hasRegistration := builder.(file.HasRegistration); hasRegistration {
+			builderWithRegistration.InjectRegistration(u.Config.Registration)
+		}
 	}
 	// Inject resource
 	if u.Resource != nil {
@@ -0,0 +1,93 @@
+package external
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// DefaultDirs returns the directories where external plugins are discovered by default: those of
+// PATH, followed by the kubeapi/plugins directory of the user configuration directory
+func DefaultDirs() []string {
+	dirs := filepath.SplitList(os.Getenv("PATH"))
+	if configDir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(configDir, "kubeapi", "plugins"))
+	}
+	return dirs
+}
+
+// Executable is the executable of an external plugin, which is not loaded yet
+type Executable struct {
+	// Name is the name of the executable without its prefix, which usually is the short name of the plugin
+	Name string
+	// Path is the path of the executable
+	Path string
+}
+
+// Find returns the executables of the external plugins found in dirs, which are named prefix<name>, without
+// loading them. When several executables have the same name, the one found first is returned.
+func Find(prefix string, dirs ...string) []Executable {
+	var executables []Executable
+	seen := make(map[string]struct{})
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			// Missing or unreadable directories of PATH are not an error
+			continue
+		}
+		for _, info := range infos {
+			name := info.Name()
+			if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) || !isExecutable(info) {
+				continue
+			}
+			if _, found := seen[name]; found {
+				continue
+			}
+			seen[name] = struct{}{}
+
+			executables = append(executables, Executable{
+				Name: strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".exe"),
+				Path: filepath.Join(dir, name),
+			})
+		}
+	}
+	return executables
+}
+
+// Discover returns the external plugins found in dirs, which are the executables named prefix<name>.
+// When several executables have the same name, the one found first is used. Executables that do not
+// respond to the metadata request are reported in the returned errors, without preventing the
+// discovery of the others. The plugins log with logger.
+func Discover(prefix string, logger logr.Logger, dirs ...string) ([]*Plugin, []error) {
+	var (
+		plugins []*Plugin
+		errs    []error
+	)
+	for _, executable := range Find(prefix, dirs...) {
+		p, err := Load(executable.Path, logger)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, errs
+}
+
+// isExecutable returns true if info describes an executable file
+func isExecutable(info os.FileInfo) bool {
+	if info.IsDir() {
+		return false
+	}
+	// The permission bits of Windows files do not tell if they are executable
+	if filepath.Ext(info.Name()) == ".exe" {
+		return true
+	}
+	return info.Mode()&0111 != 0
+}
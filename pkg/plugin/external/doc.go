@@ -0,0 +1,48 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package external runs plugins that are executables instead of being compiled into the cli.
+//
+// External plugins are executables named <command>-<name> (e.g. kubeapi-crd) found in the
+// directories of PATH or in the kubeapi/plugins directory of the user configuration directory
+// (e.g. ~/.config/kubeapi/plugins). They are driven through a JSON protocol: every invocation
+// writes a Request to the standard input of the executable and reads a Response from its standard
+// output. The standard error of the executable is forwarded to the user.
+//
+// The first request of every plugin asks for its Metadata, which the cli only sends to the plugins selected
+// with --plugins or the layout of the project, or listed or described with the plugins command:
+//
+//	{"apiVersion": "v1alpha1", "command": "metadata"}
+//
+//	{"apiVersion": "v1alpha1", "metadata": {
+//	  "name": "crd.example.com", "version": "v1", "supportedProjectVersions": ["1"],
+//	  "commands": {"create-api": {"description": "...", "flags": [{"name": "scope", "type": "string"}]}}}}
+//
+// The following requests run one of the commands listed in the metadata (see the Command* constants),
+// with the values of the flags declared for it and the universe of the scaffold: the project
+// configuration, the boilerplate and, for create-api, the resource set with the --group, --version,
+// --kind and --namespaced flags. The response lists the files to write, with what to do if they
+// already exist ("skip", "error" or "overwrite", "error" by default), and optionally the updated
+// project configuration:
+//
+//	{"apiVersion": "v1alpha1", "command": "create-api", "flags": {"scope": "Cluster"},
+//	  "universe": {"config": {...}, "boilerplate": "...", "resource": {...}}}
+//
+//	{"apiVersion": "v1alpha1", "files": [{"path": "config/crd/frigates.yaml", "contents": "...",
+//	  "ifExistsAction": "overwrite"}]}
+//
+// A plugin reports a failure by setting the error field of the response.
+package external
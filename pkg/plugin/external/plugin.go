@@ -0,0 +1,160 @@
+package external
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/seamounts/kubeapi/pkg/plugin"
+)
+
+var (
+	_ plugin.Base                  = &Plugin{}
+	_ plugin.InitPluginGetter      = &Plugin{}
+	_ plugin.CreateAPIPluginGetter = &Plugin{}
+)
+
+// Plugin is an external plugin
+type Plugin struct {
+	// path is the path of the executable
+	path string
+	// metadata is the response of the executable to the metadata request
+	metadata Metadata
+	// logger logs the flags of the metadata that are ignored
+	logger logr.Logger
+}
+
+// Load returns the external plugin of the executable at path, requesting its metadata. The plugin logs
+// with logger, or with klog if it is nil.
+func Load(path string, logger logr.Logger) (*Plugin, error) {
+	if logger == nil {
+		logger = klogr.New()
+	}
+	p := &Plugin{path: path, logger: logger}
+
+	resp, err := p.call(Request{Command: CommandMetadata})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Metadata == nil {
+		return nil, p.errorf("no metadata in the response to the metadata request")
+	}
+	p.metadata = *resp.Metadata
+
+	if err := plugin.ValidateName(p.metadata.Name); err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	if err := plugin.ValidateVersion(p.metadata.Version); err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	if len(p.metadata.SupportedProjectVersions) == 0 {
+		return nil, p.errorf("no supported project versions")
+	}
+
+	return p, nil
+}
+
+// Name implements plugin.Base
+func (p Plugin) Name() string {
+	return p.metadata.Name
+}
+
+// Version implements plugin.Base
+func (p Plugin) Version() string {
+	return p.metadata.Version
+}
+
+// SupportedProjectVersions implements plugin.Base
+func (p Plugin) SupportedProjectVersions() []string {
+	return p.metadata.SupportedProjectVersions
+}
+
+// Path returns the path of the executable of the plugin
+func (p Plugin) Path() string {
+	return p.path
+}
+
+// Commands returns the names of the commands implemented by the plugin, sorted
+func (p Plugin) Commands() []string {
+	commands := make([]string, 0, len(p.metadata.Commands))
+	for name := range p.metadata.Commands {
+		commands = append(commands, name)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// GetInitPlugin implements plugin.InitPluginGetter, returning nil if the plugin does not implement CommandInit
+func (p *Plugin) GetInitPlugin() plugin.Init {
+	if sub := p.subcommand(CommandInit); sub != nil {
+		return sub
+	}
+	return nil
+}
+
+// GetCreateAPIPlugin implements plugin.CreateAPIPluginGetter, returning nil if the plugin does not
+// implement CommandCreateAPI
+func (p *Plugin) GetCreateAPIPlugin() plugin.CreateAPI {
+	if sub := p.subcommand(CommandCreateAPI); sub != nil {
+		return sub
+	}
+	return nil
+}
+
+// subcommand returns the subcommand that runs a command of the plugin, or nil if it is not implemented
+func (p *Plugin) subcommand(name string) *subcommand {
+	command, found := p.metadata.Commands[name]
+	if !found {
+		return nil
+	}
+	return &subcommand{plugin: p, name: name, command: command}
+}
+
+// call sends a request to the plugin run in the working directory of the process and returns its response
+func (p *Plugin) call(req Request) (*Response, error) {
+	return p.callIn(plugin.Runtime{Stderr: os.Stderr}, req)
+}
+
+// callIn sends a request to the plugin run in the project root of rt and returns its response.
+// The diagnostics of the plugin are written to the stderr of rt.
+func (p *Plugin) callIn(rt plugin.Runtime, req Request) (*Response, error) {
+	req.APIVersion = APIVersion
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &bytes.Buffer{}
+	cmd := exec.Command(p.path) // nolint:gosec
+	cmd.Dir = rt.ProjectRoot
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = out
+	cmd.Stderr = rt.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, p.errorf("%s request failed: %v", req.Command, err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return nil, p.errorf("invalid response to the %s request: %v", req.Command, err)
+	}
+	if resp.APIVersion != APIVersion {
+		return nil, p.errorf("unsupported protocol version %q, expected %q", resp.APIVersion, APIVersion)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+
+	return &resp, nil
+}
+
+func (p Plugin) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("external plugin %s: %s", p.path, fmt.Sprintf(format, args...))
+}
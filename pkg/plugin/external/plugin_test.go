@@ -0,0 +1,278 @@
+package external
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+
+	"github.com/seamounts/kubeapi/pkg/model"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+)
+
+// behaviorEnv is the environment variable that makes the test binary run as an external plugin, which responds
+// to the request read from its standard input as described by the value of the variable
+const behaviorEnv = "KUBEAPI_TEST_EXTERNAL_PLUGIN"
+
+// Behaviors of the test plugin
+const (
+	// behaviorEcho responds with testMetadata, or with a request.json file of the request of other commands
+	behaviorEcho = "echo"
+	// behaviorError responds with an error
+	behaviorError = "error"
+	// behaviorExit writes a diagnostic to its standard error and exits with a non-zero code
+	behaviorExit = "exit"
+	// behaviorVersion responds with an unknown version of the protocol
+	behaviorVersion = "version"
+	// behaviorInvalid responds with invalid JSON
+	behaviorInvalid = "invalid"
+)
+
+var testMetadata = Metadata{
+	Name:                     "echo.example.com",
+	Version:                  "v1.2.3",
+	SupportedProjectVersions: []string{config.Version1},
+	Commands: map[string]Command{
+		CommandCreateAPI: {
+			Description: "Echo the request",
+			Flags:       []Flag{{Name: "image", Default: "nginx"}, {Name: "paused", Type: FlagTypeBool}},
+		},
+	},
+}
+
+func TestMain(m *testing.M) {
+	if behavior := os.Getenv(behaviorEnv); behavior != "" {
+		os.Exit(runPlugin(behavior))
+	}
+	os.Exit(m.Run())
+}
+
+// runPlugin responds to the request read from the standard input with the provided behavior and returns the
+// exit code of the plugin
+func runPlugin(behavior string) int {
+	var req Request
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid request: %v\n", err)
+		return 1
+	}
+
+	resp := Response{APIVersion: APIVersion}
+	switch behavior {
+	case behaviorEcho:
+		if req.Command == CommandMetadata {
+			resp.Metadata = &testMetadata
+			break
+		}
+		in, err := json.Marshal(req)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		resp.Files = []File{{Path: "request.json", Contents: string(in)}}
+	case behaviorError:
+		resp.Error = "the plugin failed"
+	case behaviorExit:
+		fmt.Fprintln(os.Stderr, "the plugin crashed")
+		return 3
+	case behaviorVersion:
+		resp.APIVersion = "v2"
+	case behaviorInvalid:
+		fmt.Print("not JSON")
+		return 0
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(resp); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// testPlugin returns the plugin of the test binary, run with the provided behavior until the returned
+// function is called
+func testPlugin(t *testing.T, behavior string) (*Plugin, func()) {
+	t.Helper()
+	path, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv(behaviorEnv, behavior); err != nil {
+		t.Fatal(err)
+	}
+	return &Plugin{path: path}, func() { os.Unsetenv(behaviorEnv) }
+}
+
+func TestLoad(t *testing.T) {
+	p, done := testPlugin(t, behaviorEcho)
+	defer done()
+
+	loaded, err := Load(p.Path(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.metadata, testMetadata) {
+		t.Errorf("metadata = %+v, want %+v", loaded.metadata, testMetadata)
+	}
+	if commands := loaded.Commands(); !reflect.DeepEqual(commands, []string{CommandCreateAPI}) {
+		t.Errorf("Commands() = %v", commands)
+	}
+	if loaded.GetInitPlugin() != nil || loaded.GetCreateAPIPlugin() == nil {
+		t.Error("the subcommands do not match the commands of the metadata")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for behavior, expected := range map[string]string{
+		behaviorError:   "the plugin failed",
+		behaviorExit:    "metadata request failed: exit status 3",
+		behaviorVersion: `unsupported protocol version "v2", expected "v1alpha1"`,
+		behaviorInvalid: "invalid response to the metadata request",
+	} {
+		t.Run(behavior, func(t *testing.T) {
+			p, done := testPlugin(t, behavior)
+			defer done()
+
+			_, err := Load(p.Path(), nil)
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected an error with %q, got %v", expected, err)
+			}
+		})
+	}
+}
+
+func TestCallStderr(t *testing.T) {
+	p, done := testPlugin(t, behaviorExit)
+	defer done()
+
+	stderr := &bytes.Buffer{}
+	if _, err := p.callIn(plugin.Runtime{Stderr: stderr}, Request{Command: CommandCreateAPI}); err == nil {
+		t.Error("expected an error for the non-zero exit code")
+	}
+	if stderr.String() != "the plugin crashed\n" {
+		t.Errorf("the diagnostics of the plugin are %q", stderr)
+	}
+}
+
+func TestRequest(t *testing.T) {
+	p, done := testPlugin(t, behaviorEcho)
+	defer done()
+
+	resp, err := p.callIn(plugin.Runtime{Stderr: ioutil.Discard}, Request{
+		Command:  CommandCreateAPI,
+		Flags:    map[string]string{"image": "busybox"},
+		Universe: model.NewUniverse(model.WithConfig(&config.Config{Version: config.Version1, Repo: "example.com/project"})),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Files) != 1 {
+		t.Fatalf("unexpected files %+v", resp.Files)
+	}
+
+	// The request is encoded with the field names of the protocol, and its version is set
+	var req map[string]interface{}
+	if err := json.Unmarshal([]byte(resp.Files[0].Contents), &req); err != nil {
+		t.Fatal(err)
+	}
+	if req["apiVersion"] != APIVersion || req["command"] != CommandCreateAPI {
+		t.Errorf("unexpected request %v", req)
+	}
+	if flags, _ := req["flags"].(map[string]interface{}); flags["image"] != "busybox" {
+		t.Errorf("unexpected flags %v", req["flags"])
+	}
+	universe, _ := req["universe"].(map[string]interface{})
+	if cfg, _ := universe["config"].(map[string]interface{}); cfg["repo"] != "example.com/project" {
+		t.Errorf("unexpected universe %v", req["universe"])
+	}
+}
+
+func TestSubcommand(t *testing.T) {
+	p, done := testPlugin(t, behaviorEcho)
+	defer done()
+
+	loaded, err := Load(p.Path(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := loaded.subcommand(CommandCreateAPI)
+	fs := pflag.NewFlagSet("create api", pflag.ContinueOnError)
+	sub.BindFlags(fs)
+	if err := fs.Parse([]string{"--group", "ship", "--version", "v1", "--kind", "Frigate", "--paused"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Version: config.Version1, Repo: "example.com/project", Domain: "example.com"}
+	sub.InjectConfig(cfg)
+	projectFs := afero.NewMemMapFs()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub.InjectRuntime(plugin.Runtime{FS: projectFs, ProjectRoot: wd, Stdout: ioutil.Discard, Stderr: ioutil.Discard})
+	if err := sub.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The plugin received the values of its flags and the resource, which is tracked
+	contents, err := afero.ReadFile(projectFs, "request.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req Request
+	if err := json.Unmarshal(contents, &req); err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string]string{"image": "nginx", "paused": "true"}; !reflect.DeepEqual(req.Flags, expected) {
+		t.Errorf("flags = %v, want %v", req.Flags, expected)
+	}
+	if req.Universe == nil || req.Universe.Resource == nil || req.Universe.Resource.Kind != "Frigate" {
+		t.Errorf("unexpected universe %+v", req.Universe)
+	}
+	if !cfg.HasResource(config.GVK{Group: "ship", Version: "v1", Kind: "Frigate"}) {
+		t.Errorf("the resource is not tracked in %+v", cfg.Resources)
+	}
+}
+
+// recordingLogger records the messages of the info logs
+type recordingLogger struct {
+	logrtesting.NullLogger
+
+	messages *[]string
+}
+
+func (l recordingLogger) Info(msg string, _ ...interface{}) {
+	*l.messages = append(*l.messages, msg)
+}
+
+func TestBindFlagsIgnored(t *testing.T) {
+	var messages []string
+	p := &Plugin{
+		metadata: Metadata{Name: "echo.example.com", Version: "v1", Commands: map[string]Command{
+			CommandCreateAPI: {Flags: []Flag{{Name: "kind"}, {Name: "replicas", Type: "int"}, {Name: "image"}}},
+		}},
+		logger: recordingLogger{messages: &messages},
+	}
+	sub := p.subcommand(CommandCreateAPI)
+	fs := pflag.NewFlagSet("create api", pflag.ContinueOnError)
+	sub.BindFlags(fs)
+
+	// The flag that is already defined and the one of an unknown type are logged with the logger of the plugin
+	expected := []string{
+		"ignoring a flag of an external plugin that is already defined",
+		"ignoring a flag of an external plugin with an unknown type",
+	}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("logged %q, want %q", messages, expected)
+	}
+	if fs.Lookup("replicas") != nil || fs.Lookup("image") == nil {
+		t.Error("the flags of the plugin were not bound as expected")
+	}
+}
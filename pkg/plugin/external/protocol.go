@@ -0,0 +1,112 @@
+package external
+
+import (
+	"fmt"
+
+	"github.com/seamounts/kubeapi/pkg/model"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/file"
+)
+
+// APIVersion is the version of the protocol
+const APIVersion = "v1alpha1"
+
+// Commands of the protocol
+const (
+	// CommandMetadata asks the plugin for its Metadata
+	CommandMetadata = "metadata"
+	// CommandInit runs the init subcommand
+	CommandInit = "init"
+	// CommandCreateAPI runs the create api subcommand
+	CommandCreateAPI = "create-api"
+)
+
+// Flag types
+const (
+	FlagTypeString = "string"
+	FlagTypeBool   = "bool"
+)
+
+// Request is written to the standard input of the plugin
+type Request struct {
+	// APIVersion is the version of the protocol
+	APIVersion string `json:"apiVersion"`
+	// Command is what the plugin must do
+	Command string `json:"command"`
+	// Flags are the values of the flags the plugin declared for Command
+	Flags map[string]string `json:"flags,omitempty"`
+	// Universe is the data of the scaffold
+	Universe *model.Universe `json:"universe,omitempty"`
+}
+
+// Response is read from the standard output of the plugin
+type Response struct {
+	// APIVersion is the version of the protocol
+	APIVersion string `json:"apiVersion"`
+	// Metadata describes the plugin, in response to CommandMetadata
+	Metadata *Metadata `json:"metadata,omitempty"`
+	// Config is the updated project configuration, if the plugin modified it
+	Config *config.Config `json:"config,omitempty"`
+	// Files are the files to write
+	Files []File `json:"files,omitempty"`
+	// Error reports that the command failed
+	Error string `json:"error,omitempty"`
+}
+
+// Metadata describes a plugin
+type Metadata struct {
+	// Name is the DNS1123 subdomain name of the plugin
+	Name string `json:"name"`
+	// Version is the semantic version of the plugin
+	Version string `json:"version"`
+	// SupportedProjectVersions are the project configuration versions supported by the plugin
+	SupportedProjectVersions []string `json:"supportedProjectVersions"`
+	// Commands are the commands implemented by the plugin, by name
+	Commands map[string]Command `json:"commands,omitempty"`
+}
+
+// Command describes a command of a plugin
+type Command struct {
+	// Description is displayed in the help of the command
+	Description string `json:"description,omitempty"`
+	// Examples are displayed in the help of the command
+	Examples string `json:"examples,omitempty"`
+	// Flags are the flags of the command
+	Flags []Flag `json:"flags,omitempty"`
+}
+
+// Flag describes a flag of a command
+type Flag struct {
+	// Name is the name of the flag, without dashes
+	Name string `json:"name"`
+	// Type is either FlagTypeString (default) or FlagTypeBool
+	Type string `json:"type,omitempty"`
+	// Default is the default value of the flag
+	Default string `json:"default,omitempty"`
+	// Usage is displayed in the help of the command
+	Usage string `json:"usage,omitempty"`
+}
+
+// File is a file to write
+type File struct {
+	// Path is the path of the file, relative to the project root
+	Path string `json:"path"`
+	// Contents are the contents of the file
+	Contents string `json:"contents"`
+	// IfExistsAction is what to do if the file already exists: "skip", "error" (default) or "overwrite"
+	IfExistsAction string `json:"ifExistsAction,omitempty"`
+}
+
+// ifExistsAction returns the file.IfExistsAction of f
+func (f File) ifExistsAction() (file.IfExistsAction, error) {
+	switch f.IfExistsAction {
+	case "skip":
+		return file.Skip, nil
+	case "", "error":
+		return file.Error, nil
+	case "overwrite":
+		return file.Overwrite, nil
+	default:
+		return 0, fmt.Errorf("unknown ifExistsAction %q of file %s", f.IfExistsAction, f.Path)
+	}
+}
@@ -0,0 +1,200 @@
+package external
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+
+	"github.com/seamounts/kubeapi/internal/cmdutil"
+	"github.com/seamounts/kubeapi/pkg/model"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+	"github.com/seamounts/kubeapi/pkg/plugin/internal"
+	"github.com/seamounts/kubeapi/pkg/scaffold"
+)
+
+var (
+	_ plugin.Init        = &subcommand{}
+	_ plugin.CreateAPI   = &subcommand{}
+	_ plugin.DryRunner   = &subcommand{}
+	_ plugin.HasRuntime  = &subcommand{}
+	_ cmdutil.RunOptions = &subcommand{}
+)
+
+// subcommand runs a command of an external plugin
+type subcommand struct {
+	plugin  *Plugin
+	name    string
+	command Command
+
+	config *config.Config
+
+	// resource is set with the resource flags of CommandCreateAPI
+	resource *resource.Options
+
+	// flags returns the values of the flags declared by the plugin
+	flags map[string]func() string
+
+	dryRun bool
+	rt     plugin.Runtime
+}
+
+// UpdateContext implements plugin.GenericSubcommand
+func (s *subcommand) UpdateContext(ctx *plugin.Context) {
+	if s.command.Description != "" {
+		ctx.Description = s.command.Description
+	}
+	ctx.Description = fmt.Sprintf("%s\nProvided by the external plugin %s (%s).\n",
+		strings.TrimSuffix(ctx.Description, "\n"), plugin.KeyFor(s.plugin), s.plugin.Path())
+	if s.command.Examples != "" {
+		ctx.Examples = s.command.Examples
+	}
+}
+
+// BindFlags implements plugin.GenericSubcommand
+func (s *subcommand) BindFlags(fs *pflag.FlagSet) {
+	if s.name == CommandCreateAPI {
+		s.resource = &resource.Options{}
+		fs.StringVar(&s.resource.Kind, "kind", "", "resource Kind")
+		fs.StringVar(&s.resource.Group, "group", "", "resource Group")
+		fs.StringVar(&s.resource.Version, "version", "", "resource Version")
+		fs.BoolVar(&s.resource.Namespaced, "namespaced", true, "resource is namespaced")
+	}
+	fs.BoolVar(&s.dryRun, "dry-run", false, "print the files that would be created, updated or skipped, "+
+		"and the diffs of the updated ones, without writing them or saving the PROJECT file")
+
+	s.flags = make(map[string]func() string, len(s.command.Flags))
+	for _, flag := range s.command.Flags {
+		if fs.Lookup(flag.Name) != nil {
+			s.plugin.logger.Info("ignoring a flag of an external plugin that is already defined",
+				"plugin", plugin.KeyFor(s.plugin), "flag", flag.Name)
+			continue
+		}
+
+		switch flag.Type {
+		case "", FlagTypeString:
+			value := fs.String(flag.Name, flag.Default, flag.Usage)
+			s.flags[flag.Name] = func() string { return *value }
+		case FlagTypeBool:
+			defaultValue, _ := strconv.ParseBool(flag.Default)
+			value := fs.Bool(flag.Name, defaultValue, flag.Usage)
+			s.flags[flag.Name] = func() string { return strconv.FormatBool(*value) }
+		default:
+			s.plugin.logger.Info("ignoring a flag of an external plugin with an unknown type",
+				"plugin", plugin.KeyFor(s.plugin), "flag", flag.Name, "type", flag.Type)
+		}
+	}
+}
+
+// InjectConfig implements plugin.GenericSubcommand
+func (s *subcommand) InjectConfig(c *config.Config) {
+	s.config = c
+}
+
+// DryRun implements plugin.DryRunner
+func (s *subcommand) DryRun() bool {
+	return s.dryRun
+}
+
+// InjectRuntime implements plugin.HasRuntime
+func (s *subcommand) InjectRuntime(rt plugin.Runtime) {
+	s.rt = rt
+}
+
+// Run implements plugin.GenericSubcommand
+func (s *subcommand) Run() error {
+	rt, err := s.rt.WithDefaults("")
+	if err != nil {
+		return err
+	}
+	s.rt = rt
+	return cmdutil.Run(s, s.rt)
+}
+
+// Validate implements cmdutil.RunOptions
+func (s *subcommand) Validate() error {
+	if s.resource != nil {
+		if err := s.resource.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Try to guess repository if it is not set yet.
+	if s.config.Repo == "" {
+		repoPath, err := internal.FindCurrentRepo(s.rt.ProjectRoot)
+		if err != nil {
+			return fmt.Errorf("error finding current repository: %v", err)
+		}
+		s.config.Repo = repoPath
+	}
+
+	return nil
+}
+
+// GetScaffolder implements cmdutil.RunOptions
+func (s *subcommand) GetScaffolder() (scaffold.Scaffolder, error) {
+	options := []model.UniverseOption{model.WithConfig(s.config)}
+
+	// Load the boilerplate, if the project already has one
+	bp, err := afero.ReadFile(s.rt.FS, filepath.Join("hack", "boilerplate.go.txt"))
+	if err == nil {
+		options = append(options, model.WithBoilerplate(string(bp)))
+	}
+
+	if s.resource != nil {
+		options = append(options, model.WithResource(s.resource.NewResource(s.config)))
+	}
+
+	flags := make(map[string]string, len(s.flags))
+	for name, value := range s.flags {
+		flags[name] = value()
+	}
+
+	resp, err := s.plugin.callIn(s.rt, Request{
+		Command:  s.name,
+		Flags:    flags,
+		Universe: model.NewUniverse(options...),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The project version is managed by the cli
+	if resp.Config != nil {
+		version := s.config.Version
+		*s.config = *resp.Config
+		s.config.Version = version
+	}
+
+	files := make([]file.File, 0, len(resp.Files))
+	for _, f := range resp.Files {
+		path := filepath.Clean(f.Path)
+		if filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+			return nil, s.plugin.errorf("file %s is outside of the project", f.Path)
+		}
+		action, err := f.ifExistsAction()
+		if err != nil {
+			return nil, s.plugin.errorf("%v", err)
+		}
+		files = append(files, file.File{Path: path, Contents: f.Contents, IfExistsAction: action})
+	}
+
+	return scaffold.NewFilesScaffolder(s.config, files, scaffold.WithOutput(s.rt.Stdout)), nil
+}
+
+// PostScaffold implements cmdutil.RunOptions
+func (s *subcommand) PostScaffold() error {
+	if s.resource != nil {
+		s.config.AddResource(s.resource.GVK())
+		if s.rt.RecordResource != nil {
+			s.rt.RecordResource(s.resource.GVK())
+		}
+	}
+	return nil
+}
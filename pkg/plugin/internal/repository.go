@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 
 	"golang.org/x/tools/go/packages"
 )
@@ -33,9 +34,10 @@ type module struct {
 	Path string
 }
 
-// findGoModulePath finds the path of the current module, if present.
-func findGoModulePath(forceModules bool) (string, error) {
+// findGoModulePath finds the path of the module in dir, if present.
+func findGoModulePath(dir string, forceModules bool) (string, error) {
 	cmd := exec.Command("go", "mod", "edit", "-json")
+	cmd.Dir = dir
 	cmd.Env = append(cmd.Env, os.Environ()...)
 	if forceModules {
 		cmd.Env = append(cmd.Env, "GO111MODULE=on" /* turn on modules just for these commands */)
@@ -54,11 +56,11 @@ func findGoModulePath(forceModules bool) (string, error) {
 	return mod.Module.Path, nil
 }
 
-// FindCurrentRepo attempts to determine the current repository
+// FindCurrentRepo attempts to determine the repository of the project in dir
 // though a combination of go/packages and `go mod` commands/tricks.
-func FindCurrentRepo() (string, error) {
+func FindCurrentRepo(dir string) (string, error) {
 	// easiest case: existing go module
-	path, err := findGoModulePath(false)
+	path, err := findGoModulePath(dir, false)
 	if err == nil {
 		return path, nil
 	}
@@ -66,6 +68,7 @@ func FindCurrentRepo() (string, error) {
 	// next, check if we've got a package in the current directory
 	pkgCfg := &packages.Config{
 		Mode: packages.NeedName, // name gives us path as well
+		Dir:  dir,
 	}
 	pkgs, err := packages.Load(pkgCfg, ".")
 	// NB(directxman12): when go modules are off and we're outside GOPATH and
@@ -78,6 +81,7 @@ func FindCurrentRepo() (string, error) {
 
 	// otherwise, try to get `go mod init` to guess for us -- it's pretty good
 	cmd := exec.Command("go", "mod", "init")
+	cmd.Dir = dir
 	cmd.Env = append(cmd.Env, os.Environ()...)
 	cmd.Env = append(cmd.Env, "GO111MODULE=on" /* turn on modules just for these commands */)
 	if _, err := cmd.Output(); err != nil {
@@ -88,6 +92,6 @@ func FindCurrentRepo() (string, error) {
 		return "", fmt.Errorf("could not determine repository path from module data, "+
 			"package data, or by initializing a module: %v", err)
 	}
-	defer os.Remove("go.mod") // clean up after ourselves
-	return findGoModulePath(true)
+	defer os.Remove(filepath.Join(dir, "go.mod")) // clean up after ourselves
+	return findGoModulePath(dir, true)
 }
@@ -66,6 +66,13 @@ type GenericSubcommand interface {
 	InjectConfig(*config.Config)
 }
 
+// DryRunner is implemented by subcommands that can report the changes they would make instead of
+// making them. The cli does not save the project configuration of a dry run.
+type DryRunner interface {
+	// DryRun returns true if the subcommand must not make any change
+	DryRun() bool
+}
+
 type Context struct {
 	// CommandName sets the command name for a plugin.
 	CommandName string
@@ -95,3 +102,63 @@ type CreateAPIPluginGetter interface {
 type CreateAPI interface {
 	GenericSubcommand
 }
+
+type CreateAPIsPluginGetter interface {
+	Base
+	// GetCreateAPIsPlugin returns the underlying CreateAPIs interface.
+	GetCreateAPIsPlugin() CreateAPIs
+}
+
+type CreateAPIs interface {
+	GenericSubcommand
+}
+
+type RenameKindPluginGetter interface {
+	Base
+	// GetRenameKindPlugin returns the underlying RenameKind interface.
+	GetRenameKindPlugin() RenameKind
+}
+
+type RenameKind interface {
+	GenericSubcommand
+}
+
+type MoveKindPluginGetter interface {
+	Base
+	// GetMoveKindPlugin returns the underlying MoveKind interface.
+	GetMoveKindPlugin() MoveKind
+}
+
+type MoveKind interface {
+	GenericSubcommand
+}
+
+type ImportCRDPluginGetter interface {
+	Base
+	// GetImportCRDPlugin returns the underlying ImportCRD interface.
+	GetImportCRDPlugin() ImportCRD
+}
+
+type ImportCRD interface {
+	GenericSubcommand
+}
+
+type GenerateClientPluginGetter interface {
+	Base
+	// GetGenerateClientPlugin returns the underlying GenerateClient interface.
+	GetGenerateClientPlugin() GenerateClient
+}
+
+type GenerateClient interface {
+	GenericSubcommand
+}
+
+type ExportTemplatesPluginGetter interface {
+	Base
+	// GetExportTemplatesPlugin returns the underlying ExportTemplates interface.
+	GetExportTemplatesPlugin() ExportTemplates
+}
+
+type ExportTemplates interface {
+	GenericSubcommand
+}
@@ -0,0 +1,19 @@
+package plugin
+
+import (
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+)
+
+// PresetProvider is implemented by plugins that contribute presets of Kinds to the create api subcommands,
+// in addition to the built-in ones
+type PresetProvider interface {
+	Base
+	// Presets returns the presets of Kinds the plugin contributes
+	Presets() []resource.Preset
+}
+
+// HasPresets is implemented by subcommands that scaffold Kinds of the presets contributed by plugins
+type HasPresets interface {
+	// InjectPresets passes the presets contributed by the plugins the subcommand runs with
+	InjectPresets([]resource.Preset)
+}
@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/afero"
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/file"
+)
+
+// Runtime is the environment subcommands run in. It is provided by the cli instead of being taken from
+// the process, so that kubeapi can be embedded in other tools and run against an in-memory file system.
+type Runtime struct {
+	// Stdin is where subcommands read the answers of users to their prompts
+	Stdin io.Reader
+	// Stdout is where subcommands write the messages for users
+	Stdout io.Writer
+	// Stderr is where subcommands write their diagnostics, e.g. the ones of the tools they run
+	Stderr io.Writer
+	// FS is the file system of the project, rooted at ProjectRoot: project files are read and written
+	// through it with paths relative to the project root
+	FS afero.Fs
+	// ProjectRoot is the absolute path of the project root directory, used by the tools that need to run in it
+	ProjectRoot string
+	// Logger logs the progress of subcommands
+	Logger logr.Logger
+	// Interactive is true if subcommands may prompt users on Stdout for the information missing from their flags
+	Interactive bool
+
+	// RecordFile, if set, is called with every change made to a scaffolded file, including the skipped ones
+	RecordFile func(file.Change)
+	// RecordGenerator, if set, is called after a code generator ran, with how long it took
+	RecordGenerator func(name string, elapsed time.Duration)
+	// RecordResource, if set, is called with every resource that is created, renamed or moved, with its new GVK
+	RecordResource func(config.GVK)
+}
+
+// NewRuntime returns the runtime of the project rooted at dir on disk, using the standard streams of the process
+func NewRuntime(dir string) (Runtime, error) {
+	return Runtime{}.WithDefaults(dir)
+}
+
+// WithDefaults returns a copy of r whose unset fields are set to the ones of the project rooted at dir on disk,
+// using the standard streams of the process. dir defaults to the working directory.
+func (r Runtime) WithDefaults(dir string) (Runtime, error) {
+	if r.ProjectRoot == "" {
+		if dir == "" {
+			wd, err := os.Getwd()
+			if err != nil {
+				return r, fmt.Errorf("unable to get the working directory: %v", err)
+			}
+			dir = wd
+		}
+		root, err := filepath.Abs(dir)
+		if err != nil {
+			return r, fmt.Errorf("invalid project root %q: %v", dir, err)
+		}
+		r.ProjectRoot = root
+	}
+	if r.FS == nil {
+		r.FS = afero.NewBasePathFs(afero.NewOsFs(), r.ProjectRoot)
+	}
+	if r.Stdin == nil {
+		r.Stdin = os.Stdin
+	}
+	if r.Stdout == nil {
+		r.Stdout = os.Stdout
+	}
+	if r.Stderr == nil {
+		r.Stderr = os.Stderr
+	}
+	if r.Logger == nil {
+		r.Logger = klogr.New()
+	}
+	return r, nil
+}
+
+// HasRuntime is implemented by subcommands that run in a runtime provided by the cli. The changes made to
+// the project files through its file system are made in a transaction with the saving of the project
+// configuration, so that they are all rolled back if the subcommand or the saving fails.
+type HasRuntime interface {
+	// InjectRuntime passes the runtime the subcommand must run in
+	InjectRuntime(Runtime)
+}
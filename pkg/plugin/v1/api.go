@@ -3,44 +3,106 @@ package v1
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"path/filepath"
+	"strings"
 
+	"github.com/gobuffalo/flect"
 	"github.com/seamounts/kubeapi/internal/cmdutil"
+	"github.com/seamounts/kubeapi/internal/prompt"
 	"github.com/seamounts/kubeapi/pkg/codegen"
 	"github.com/seamounts/kubeapi/pkg/model/config"
 	"github.com/seamounts/kubeapi/pkg/model/resource"
 	"github.com/seamounts/kubeapi/pkg/plugin"
 	"github.com/seamounts/kubeapi/pkg/scaffold"
+	"github.com/spf13/afero"
 	"github.com/spf13/pflag"
-	"k8s.io/klog/v2"
 )
 
 type createAPIPlugin struct {
 	config *config.Config
 
 	resource *resource.Options
+	// newResource is the resource created from the options, before it is tracked, which changes the package of
+	// the resources of the core groups
+	newResource *resource.Resource
 
 	// force indicates that the resource should be created even if it already exists
 	force bool
+
+	// generate indicates that the code generators should run after scaffolding the files
+	generate bool
+
+	// specFields and statusFields are the fields of the Kind, in the format of resource.ParseField
+	specFields, statusFields []string
+	// fieldsFile is the file the fields of the Kind are loaded from, relative to the project root
+	fieldsFile string
+
+	// preset is the name of the preset of the Kind, if any
+	preset string
+	// pluginPresets are the presets contributed by plugins
+	pluginPresets []resource.Preset
+
+	// flags are the flags of the subcommand, to tell the ones set by users
+	flags *pflag.FlagSet
+
+	dryRun
+	runtime
+	templatesDir
 }
 
 var (
 	_ plugin.CreateAPI   = &createAPIPlugin{}
+	_ plugin.HasPresets  = &createAPIPlugin{}
 	_ cmdutil.RunOptions = &createAPIPlugin{}
 )
 
 func (p createAPIPlugin) UpdateContext(ctx *plugin.Context) {
-	ctx.Description = `Scaffold a Kubernetes API by creating a Resource definition and / or a Controller.
+	var presets strings.Builder
+	for _, preset := range p.presets() {
+		fmt.Fprintf(&presets, "- %s: %s\n", preset.Name, preset.Description)
+	}
+	ctx.Description = fmt.Sprintf(`Scaffold a Kubernetes API by creating a Resource definition and / or a Controller.
+
+When the standard input and output are terminals and the group, the version or the kind is not set with flags,
+create api prompts for them, and for the scope, the plural and the subresources of the Kind that are not set
+with flags, validating every answer. --no-interactive disables the prompts.
+
+After the scaffold is written, api will run make on the project. The resource is recorded in the PROJECT file
+with its plural and its scope, and cannot be created again without --force.
+
+When the API already exists and --force is set, the scaffolded files are merged with the changes made to
+them since they were last scaffolded (as recorded under .kubeapi/). Changes that cannot be reconciled are
+surrounded by conflict markers and the command fails until they are resolved.
+
+The apis/install package, which registers every group-version of the project in a scheme and provides
+Scheme, Codecs, ParameterCodec and AddToScheme, is updated to register the group-version of the API.
 
-create resource will prompt the user for if it should scaffold the Resource and / or Controller.  To only
-scaffold a Controller for an existing Resource, select "n" for Resource.  To only define
-the schema for a Resource without writing a Controller, select "n" for Controller.
+The fields of the spec and of the status of the Kind are set with --spec-field and --status-field, as
+name:type[:options], where the type is a Go type, e.g. int32, []string, map[string]string, *metav1.Time or
+corev1.PodTemplateSpec, and the options are separated by commas:
+- required: the field must be set
+- optional: the field may be unset, and is a pointer unless it is a slice or a map
+- min=, max=, minLength=, maxLength=, minItems=, maxItems=, pattern=, enum= (values separated by ;)
+  and default=: the matching validation markers
 
-After the scaffold is written, api will run make on the project.
-`
+--from-file loads the fields from a YAML file with spec and status lists of fields, which have a name, a type,
+and optionally required, optional, doc and markers, and fields for the nested structs of the object type,
+e.g. object, []object or map[string]object.
+
+--preset scaffolds a Kind of a common shape, whose fields come before the ones set with the flags above:
+%s`, presets.String())
 	ctx.Examples = fmt.Sprintf(`  # Create a frigates API with Group: ship, Version: v1beta1 and Kind: Frigate
-  %s create api --group ship --version v1beta1 --kind Frigate
+  %[1]s create api --group ship --version v1beta1 --kind Frigate
+
+  # Create a frigates API with the fields of its spec and status
+  %[1]s create api --group ship --version v1beta1 --kind Frigate \
+    --spec-field replicas:int32:required,min=0 --spec-field image:string \
+    --status-field readyReplicas:int32
+
+  # Create a frigates API that runs replicas of a pod template
+  %[1]s create api --group ship --version v1beta1 --kind Frigate --preset workload
+
+  # Create a frigates API with the fields defined in a file
+  %[1]s create api --group ship --version v1beta1 --kind Frigate --from-file fields.yaml
 
   # Edit the API Scheme
   nano api/v1beta1/frigate_types.go
@@ -61,29 +123,83 @@ After the scaffold is written, api will run make on the project.
 }
 
 func (p *createAPIPlugin) BindFlags(fs *pflag.FlagSet) {
+	p.flags = fs
 
 	fs.BoolVar(&p.force, "force", false,
 		"attempt to create resource even if it already exists")
+	fs.BoolVar(&p.generate, "generate", true,
+		"if true, run the code generators (deepcopy, clientset, listers and informers) after scaffolding the files")
+	p.dryRun.bindFlag(fs)
+	p.templatesDir.bindFlag(fs)
 
 	p.resource = &resource.Options{}
 	fs.StringVar(&p.resource.Kind, "kind", "", "resource Kind")
 	fs.StringVar(&p.resource.Group, "group", "", "resource Group")
 	fs.StringVar(&p.resource.Version, "version", "", "resource Version")
 	fs.BoolVar(&p.resource.Namespaced, "namespaced", true, "resource is namespaced")
+	fs.StringVar(&p.resource.Plural, "plural", "", "resource plural, computed from the Kind if empty")
+	fs.StringArrayVar(&p.specFields, "spec-field", nil,
+		"field of the spec of the Kind as name:type[:options], e.g. replicas:int32:required,min=0 (repeatable)")
+	fs.StringArrayVar(&p.statusFields, "status-field", nil,
+		"field of the status of the Kind as name:type[:options], e.g. readyReplicas:int32 (repeatable)")
+	fs.StringVar(&p.fieldsFile, "from-file", "",
+		"YAML file, relative to the project root, with the spec and status fields of the Kind")
+	fs.StringVar(&p.preset, "preset", "",
+		"preset of the Kind, e.g. workload, config or conditions, which sets its fields, subresources and markers")
 }
 
 func (p *createAPIPlugin) InjectConfig(c *config.Config) {
 	p.config = c
 }
 
+// InjectPresets implements plugin.HasPresets
+func (p *createAPIPlugin) InjectPresets(presets []resource.Preset) {
+	p.pluginPresets = presets
+}
+
+// presets returns the built-in presets followed by the ones of the plugins, which cannot shadow them
+func (p createAPIPlugin) presets() []resource.Preset {
+	return append(scaffold.Presets(), p.pluginPresets...)
+}
+
 func (p *createAPIPlugin) Run() error {
-	return cmdutil.Run(p)
+	return cmdutil.Run(p, p.projectRuntime())
 }
 
 func (p *createAPIPlugin) Validate() error {
+	// Commands with the group, the version and the kind are fully specified, and do not prompt
+	prompter := p.prompter()
+	if p.resource.Group != "" && p.resource.Version != "" && p.resource.Kind != "" {
+		prompter = nil
+	}
+
+	if err := p.promptResource(prompter); err != nil {
+		return err
+	}
+	if err := p.loadFields(); err != nil {
+		return err
+	}
+	if p.preset != "" {
+		preset, err := resource.FindPreset(p.presets(), p.preset)
+		if err != nil {
+			return err
+		}
+		if err := preset.ValidateKubernetesVersion(p.config.KubernetesVersion); err != nil {
+			return err
+		}
+		p.resource.ApplyPreset(preset)
+	} else if err := p.promptSubresources(prompter); err != nil {
+		return err
+	}
+	if marker := p.resourceMarker(); marker != "" {
+		p.resource.Markers = append(p.resource.Markers, marker)
+	}
 	if err := p.resource.Validate(); err != nil {
 		return err
 	}
+	if err := p.config.ValidateRegistration(); err != nil {
+		return err
+	}
 
 	// Check that resource doesn't exist or flag force was set
 	if !p.force && p.config.HasResource(p.resource.GVK()) {
@@ -93,23 +209,186 @@ func (p *createAPIPlugin) Validate() error {
 	return nil
 }
 
+// promptResource asks users for the group, the version and the kind that are not set with flags, and for the
+// scope and the plural of the Kind, unless prompter is nil
+func (p *createAPIPlugin) promptResource(prompter *prompt.Prompter) error {
+	if prompter == nil {
+		return nil
+	}
+
+	questions := []struct {
+		question string
+		value    string
+		set      func(*resource.Options, string)
+	}{
+		{"Group", p.resource.Group, func(opts *resource.Options, group string) { opts.Group = group }},
+		{"Version", p.resource.Version, func(opts *resource.Options, version string) { opts.Version = version }},
+		{"Kind", p.resource.Kind, func(opts *resource.Options, kind string) { opts.Kind = kind }},
+	}
+	for _, q := range questions {
+		if q.value != "" {
+			continue
+		}
+		answer, err := prompter.String(q.question, "", func(answer string) error {
+			probe := gvkProbe(p.resource)
+			q.set(&probe, answer)
+			return probe.Validate()
+		})
+		if err != nil {
+			return err
+		}
+		q.set(p.resource, answer)
+	}
+
+	if !p.flags.Changed("namespaced") {
+		scope, err := prompter.Choice("Scope", []string{namespacedScope, clusterScope}, namespacedScope)
+		if err != nil {
+			return err
+		}
+		p.resource.Namespaced = scope == namespacedScope
+	}
+
+	if p.resource.Plural == "" {
+		plural := flect.Pluralize(strings.ToLower(p.resource.Kind))
+		answer, err := prompter.String("Plural", plural, func(answer string) error {
+			probe := gvkProbe(p.resource)
+			probe.Plural = answer
+			return probe.Validate()
+		})
+		if err != nil {
+			return err
+		}
+		if answer != plural {
+			p.resource.Plural = answer
+		}
+	}
+	return nil
+}
+
+// promptSubresources asks users for the subresources of a Kind without preset, unless prompter is nil
+func (p *createAPIPlugin) promptSubresources(prompter *prompt.Prompter) error {
+	if prompter == nil {
+		return nil
+	}
+
+	status, err := prompter.Bool("Enable the status subresource?", false)
+	if err != nil {
+		return err
+	}
+	p.resource.Subresources.Status = status
+
+	scale, err := prompter.Bool("Enable the scale subresource?", false)
+	if err != nil || !scale {
+		return err
+	}
+	p.resource.Subresources.Scale = &resource.ScaleSubresource{}
+	paths := []struct {
+		question, def string
+		value         *string
+	}{
+		{"JSON path of the desired replicas", ".spec.replicas", &p.resource.Subresources.Scale.SpecReplicasPath},
+		{"JSON path of the observed replicas", ".status.replicas", &p.resource.Subresources.Scale.StatusReplicasPath},
+		{"JSON path of the label selector, empty for none", "", &p.resource.Subresources.Scale.LabelSelectorPath},
+	}
+	for _, path := range paths {
+		if *path.value, err = prompter.String(path.question, path.def, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resourceMarker returns the kubebuilder:resource marker of the plural and the scope of the Kind, if they are not
+// the default ones
+func (p *createAPIPlugin) resourceMarker() string {
+	var resource []string
+	if p.resource.Plural != "" {
+		resource = append(resource, "path="+p.resource.Plural)
+	}
+	if !p.resource.Namespaced {
+		resource = append(resource, "scope="+clusterScope)
+	}
+	if len(resource) == 0 {
+		return ""
+	}
+	return "kubebuilder:resource:" + strings.Join(resource, ",")
+}
+
+// gvkProbe returns options with the group, the version and the kind of opts, and valid placeholders for the
+// ones that are not set, to validate the answers of users to the prompts inline
+func gvkProbe(opts *resource.Options) resource.Options {
+	probe := resource.Options{Group: "group", Version: "v1", Kind: "Kind"}
+	if opts.Group != "" {
+		probe.Group = opts.Group
+	}
+	if opts.Version != "" {
+		probe.Version = opts.Version
+	}
+	if opts.Kind != "" {
+		probe.Kind = opts.Kind
+	}
+	return probe
+}
+
+// loadFields sets the fields of the resource to the ones of the file, if any, followed by the ones of the flags
+func (p *createAPIPlugin) loadFields() error {
+	if p.fieldsFile != "" {
+		data, err := afero.ReadFile(p.projectFs(), p.fieldsFile)
+		if err != nil {
+			return fmt.Errorf("unable to read the fields: %v", err)
+		}
+		spec, status, err := resource.LoadFields(data)
+		if err != nil {
+			return fmt.Errorf("invalid fields in %s: %v", p.fieldsFile, err)
+		}
+		p.resource.SpecFields = append(p.resource.SpecFields, spec...)
+		p.resource.StatusFields = append(p.resource.StatusFields, status...)
+	}
+
+	for _, f := range p.specFields {
+		field, err := resource.ParseField(f)
+		if err != nil {
+			return err
+		}
+		p.resource.SpecFields = append(p.resource.SpecFields, field)
+	}
+	for _, f := range p.statusFields {
+		field, err := resource.ParseField(f)
+		if err != nil {
+			return err
+		}
+		p.resource.StatusFields = append(p.resource.StatusFields, field)
+	}
+	return nil
+}
+
 func (p *createAPIPlugin) GetScaffolder() (scaffold.Scaffolder, error) {
 	// Load the boilerplate
-	bp, err := ioutil.ReadFile(filepath.Join("hack", "boilerplate.go.txt")) // nolint:gosec
+	bp, err := p.readBoilerplate()
 	if err != nil {
 		return nil, fmt.Errorf("unable to load boilerplate: %v", err)
 	}
 
 	// Create the actual resource from the resource options
-	res := p.resource.NewResource(p.config)
-	return scaffold.NewAPIScaffolder(p.config, string(bp), res), nil
+	p.newResource = p.resource.NewResource(p.config)
+	return scaffold.NewAPIScaffolder(p.config, bp, p.newResource, p.scaffoldOptions(p.templatesOption())...), nil
 }
 
 func (p *createAPIPlugin) PostScaffold() error {
-	klog.Infoln("Start Generating Client")
-	err := codegen.GetCodeGen(p.config, p.resource).Run()
-	if err != nil {
-		return err
+	gvk := trackedGVK(p.newResource)
+	p.config.TrackResource(gvk)
+	p.recordResource(gvk)
+
+	if !p.generate {
+		return nil
 	}
-	return nil
+
+	p.logger().Info("Start Generating Client")
+	rt := p.projectRuntime()
+	gen := codegen.NewCodeGen(p.config, p.newResource)
+	gen.InjectFS(rt.FS)
+	gen.InjectProjectRoot(rt.ProjectRoot)
+	gen.InjectRecord(rt.RecordGenerator)
+	gen.InjectLogger(rt.Logger)
+	return gen.Run()
 }
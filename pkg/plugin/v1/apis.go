@@ -0,0 +1,113 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+
+	"github.com/seamounts/kubeapi/internal/cmdutil"
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+	"github.com/seamounts/kubeapi/pkg/scaffold"
+)
+
+type createAPIsPlugin struct {
+	fileResources
+
+	// pluginPresets are the presets contributed by plugins
+	pluginPresets []resource.Preset
+}
+
+var (
+	_ plugin.CreateAPIs  = &createAPIsPlugin{}
+	_ plugin.HasPresets  = &createAPIsPlugin{}
+	_ cmdutil.RunOptions = &createAPIsPlugin{}
+)
+
+func (p createAPIsPlugin) UpdateContext(ctx *plugin.Context) {
+	ctx.Description = `Scaffold the Kubernetes APIs defined in a file.
+
+The file has a list of resources, which have a group, a version and a kind, and optionally a plural, a scope
+(Namespaced, the default, or Cluster), a preset, spec and status lists of fields as in the files of the
+--from-file flag of create api, noStatus, subresources (status and scale) and markers, e.g.
+
+resources:
+- group: ship
+  version: v1beta1
+  kind: Frigate
+  spec:
+  - name: replicas
+    type: int32
+  subresources:
+    status: true
+
+Every resource is validated before any file is scaffolded, the resources are scaffolded together, recorded in
+the PROJECT file with their plural and their scope, and the code generators run once at the end.
+`
+	ctx.Examples = fmt.Sprintf(`  # Create the APIs defined in apis.yaml
+  %[1]s create apis -f apis.yaml
+
+  # Create them again, merging the scaffolded files with the changes made to them
+  %[1]s create apis -f apis.yaml --force
+`,
+		ctx.CommandName)
+}
+
+func (p *createAPIsPlugin) BindFlags(fs *pflag.FlagSet) {
+	p.bindFlags(fs, "resources to create", "create")
+}
+
+// InjectPresets implements plugin.HasPresets
+func (p *createAPIsPlugin) InjectPresets(presets []resource.Preset) {
+	p.pluginPresets = presets
+}
+
+func (p *createAPIsPlugin) Run() error {
+	return cmdutil.Run(p, p.projectRuntime())
+}
+
+func (p *createAPIsPlugin) Validate() error {
+	if p.file == "" {
+		return errors.New("the file of the resources is required")
+	}
+	data, err := afero.ReadFile(p.projectFs(), p.file)
+	if err != nil {
+		return fmt.Errorf("unable to read the resources: %v", err)
+	}
+	specs, err := resource.LoadAPIs(data)
+	if err != nil {
+		return fmt.Errorf("invalid resources in %s: %v", p.file, err)
+	}
+
+	presets := append(scaffold.Presets(), p.pluginPresets...)
+	resources := make([]resource.Options, 0, len(specs))
+	for i, spec := range specs {
+		opts, err := p.options(spec, presets)
+		if err != nil {
+			return fmt.Errorf("invalid resource #%d (%s/%s, Kind=%s): %v", i+1, spec.Group, spec.Version, spec.Kind, err)
+		}
+		resources = append(resources, opts)
+	}
+	return p.loadResources(resources)
+}
+
+// options returns the validated options of the resource of spec, with its preset applied
+func (p *createAPIsPlugin) options(spec resource.APISpec, presets []resource.Preset) (resource.Options, error) {
+	opts, err := spec.Options()
+	if err != nil {
+		return opts, err
+	}
+	if spec.Preset != "" {
+		preset, err := resource.FindPreset(presets, spec.Preset)
+		if err != nil {
+			return opts, err
+		}
+		if err := preset.ValidateKubernetesVersion(p.config.KubernetesVersion); err != nil {
+			return opts, err
+		}
+		opts.ApplyPreset(preset)
+	}
+	return opts, opts.Validate()
+}
@@ -0,0 +1,23 @@
+package v1
+
+import (
+	"github.com/seamounts/kubeapi/pkg/plugin"
+	"github.com/spf13/pflag"
+)
+
+var _ plugin.DryRunner = dryRun{}
+
+// dryRun implements plugin.DryRunner for the subcommands that run through cmdutil
+type dryRun struct {
+	enabled bool
+}
+
+func (d *dryRun) bindFlag(fs *pflag.FlagSet) {
+	fs.BoolVar(&d.enabled, "dry-run", false, "print the files that would be created, updated or skipped, "+
+		"and the diffs of the updated ones, without writing them or saving the PROJECT file")
+}
+
+// DryRun implements plugin.DryRunner
+func (d dryRun) DryRun() bool {
+	return d.enabled
+}
@@ -0,0 +1,168 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/seamounts/kubeapi/pkg/codegen"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+)
+
+// inputVersionRegex matches the version of the go packages of API types, their last element
+var inputVersionRegex = regexp.MustCompile(`^v\d+(alpha\d+|beta\d+)?$`)
+
+type generateClientPlugin struct {
+	config *config.Config
+
+	// inputPackages are the go packages of the API types of other modules
+	inputPackages []string
+
+	// output is the directory the client is generated in, relative to the project root
+	output string
+
+	runtime
+}
+
+var _ plugin.GenerateClient = &generateClientPlugin{}
+
+func (p generateClientPlugin) UpdateContext(ctx *plugin.Context) {
+	ctx.Description = `Generate the clients of API types of other modules.
+
+The clientset, listers and informers of the API types of the input packages, e.g. github.com/acme/apis/foo/v1
+for the v1 version of the foo group, are generated in the output directory. The packages are resolved through
+the module graph of the project, so their modules must be required in go.mod, and nothing is scaffolded under
+apis/.
+
+The generated clients are recorded in the PROJECT file. Without input packages, the recorded clients are
+generated again, only the one of the output directory if set.
+`
+	ctx.Examples = fmt.Sprintf(`  # Generate the client of the foo/v1 API types of github.com/acme/apis in client/acme
+  %[1]s generate client --input-package github.com/acme/apis/foo/v1 --output-dir client/acme
+
+  # Generate the recorded clients again, e.g. after upgrading github.com/acme/apis
+  %[1]s generate client
+`,
+		ctx.CommandName)
+}
+
+func (p *generateClientPlugin) BindFlags(fs *pflag.FlagSet) {
+	fs.StringArrayVar(&p.inputPackages, "input-package", nil,
+		"go package of API types of another module, e.g. github.com/acme/apis/foo/v1 (can be repeated)")
+	fs.StringVar(&p.output, "output-dir", "",
+		"directory, relative to the project root, the client is generated in, e.g. client/acme")
+}
+
+func (p *generateClientPlugin) InjectConfig(c *config.Config) {
+	p.config = c
+}
+
+func (p *generateClientPlugin) Run() error {
+	clients, err := p.clients()
+	if err != nil {
+		return err
+	}
+
+	rt := p.projectRuntime()
+	for _, client := range clients {
+		p.config.AddExternalClient(client)
+
+		rt.Logger.Info("Start Generating Client", "output", client.Output)
+		gen := codegen.NewExternalCodeGen(p.config, client.InputPackages, client.Output)
+		gen.InjectFS(rt.FS)
+		gen.InjectProjectRoot(rt.ProjectRoot)
+		gen.InjectRecord(rt.RecordGenerator)
+		gen.InjectLogger(rt.Logger)
+		if err := gen.Run(); err != nil {
+			return fmt.Errorf("unable to generate the client in %s: %v", client.Output, err)
+		}
+	}
+	return nil
+}
+
+// clients returns the clients to generate, the one of the flags or the recorded ones
+func (p *generateClientPlugin) clients() ([]config.ExternalClient, error) {
+	if len(p.inputPackages) == 0 {
+		return p.recordedClients()
+	}
+
+	if p.output == "" {
+		return nil, errors.New("the output directory of the client is required")
+	}
+	output, err := validateClientOutput(p.output)
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range p.inputPackages {
+		if err := p.validateInputPackage(pkg); err != nil {
+			return nil, fmt.Errorf("invalid input package %q: %v", pkg, err)
+		}
+	}
+	return []config.ExternalClient{{InputPackages: p.inputPackages, Output: output}}, nil
+}
+
+// recordedClients returns the clients recorded in the PROJECT file, only the one of the output directory if set
+func (p *generateClientPlugin) recordedClients() ([]config.ExternalClient, error) {
+	if p.output == "" {
+		if len(p.config.ExternalClients) == 0 {
+			return nil, errors.New("no client is recorded, input packages are required")
+		}
+		return p.config.ExternalClients, nil
+	}
+
+	output := filepath.ToSlash(filepath.Clean(p.output))
+	for _, client := range p.config.ExternalClients {
+		if client.Output == output {
+			return []config.ExternalClient{client}, nil
+		}
+	}
+	return nil, fmt.Errorf("no client is recorded in %s, input packages are required", output)
+}
+
+// validateInputPackage returns an error if pkg is not the go package of the API types of a version of a group
+// of another module
+func (p *generateClientPlugin) validateInputPackage(pkg string) error {
+	if strings.HasPrefix(pkg, "-") || path.Clean(pkg) != pkg || path.IsAbs(pkg) || strings.HasPrefix(pkg, ".") {
+		return errors.New("must be a go package path")
+	}
+	if path.Dir(pkg) == "." {
+		return errors.New("must end with the group and the version of the API types, e.g. foo/v1")
+	}
+	if !inputVersionRegex.MatchString(path.Base(pkg)) {
+		return fmt.Errorf("version must match %s (was %s)", inputVersionRegex, path.Base(pkg))
+	}
+	if pkg == p.config.Repo || strings.HasPrefix(pkg, p.config.Repo+"/") {
+		return errors.New("the clients of the API types of the project are generated with its resources")
+	}
+	return nil
+}
+
+// validateClientOutput returns the cleaned output directory, relative to the project root, or an error if the
+// client cannot be generated in it
+func validateClientOutput(output string) (string, error) {
+	if filepath.IsAbs(output) {
+		return "", fmt.Errorf("output directory %s must be relative to the project root", output)
+	}
+	output = filepath.ToSlash(filepath.Clean(output))
+	switch {
+	case output == "." || output == ".." || strings.HasPrefix(output, "../"):
+		return "", fmt.Errorf("output directory %s must be inside the project", output)
+	case output == codegen.OUTPUT_DIR:
+		return "", fmt.Errorf("output directory %s is the one of the client of the project", output)
+	case output == codegen.INPUT_DIR || strings.HasPrefix(output, codegen.INPUT_DIR+"/"):
+		return "", fmt.Errorf("output directory %s must not be under %s/", output, codegen.INPUT_DIR)
+	}
+	for _, dir := range []string{codegen.CLIENTSET_PKG_NAME, "listers", "informers"} {
+		if generated := path.Join(codegen.OUTPUT_DIR, dir); output == generated ||
+			strings.HasPrefix(output, generated+"/") {
+			return "", fmt.Errorf("output directory %s must not be under %s/", output, generated)
+		}
+	}
+	return output, nil
+}
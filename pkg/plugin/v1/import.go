@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+
+	"github.com/seamounts/kubeapi/internal/cmdutil"
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+)
+
+type importCRDPlugin struct {
+	fileResources
+}
+
+var (
+	_ plugin.ImportCRD   = &importCRDPlugin{}
+	_ cmdutil.RunOptions = &importCRDPlugin{}
+)
+
+func (p importCRDPlugin) UpdateContext(ctx *plugin.Context) {
+	ctx.Description = `Import the APIs of CustomResourceDefinitions.
+
+The types of every served version of the CustomResourceDefinitions of the file, of apiextensions.k8s.io/v1
+or v1beta1, are scaffolded under apis/<group>/<version>, with the fields of their spec and of their status
+converted from their OpenAPI v3 schemas. The validations of the schemas, the subresources, the printer
+columns and the names of the resources are kept as markers.
+
+The group of the CustomResourceDefinitions is split at its first dot into the group of the APIs and its
+domain, e.g. monitoring and coreos.com for monitoring.coreos.com. The imported resources are recorded in
+the PROJECT file with their plural and their scope, and the deepcopy funcs, clientset, listers and
+informers are generated.
+`
+	ctx.Examples = fmt.Sprintf(`  # Import the CustomResourceDefinitions of crd.yaml
+  %[1]s import crd -f crd.yaml
+
+  # Import them again, merging the scaffolded files with the changes made to them
+  %[1]s import crd -f crd.yaml --force
+`,
+		ctx.CommandName)
+}
+
+func (p *importCRDPlugin) BindFlags(fs *pflag.FlagSet) {
+	p.bindFlags(fs, "CustomResourceDefinitions to import", "import")
+}
+
+func (p *importCRDPlugin) Run() error {
+	return cmdutil.Run(p, p.projectRuntime())
+}
+
+func (p *importCRDPlugin) Validate() error {
+	if p.file == "" {
+		return errors.New("the file of the CustomResourceDefinitions is required")
+	}
+	data, err := afero.ReadFile(p.projectFs(), p.file)
+	if err != nil {
+		return fmt.Errorf("unable to read the CustomResourceDefinitions: %v", err)
+	}
+	resources, err := resource.LoadCRDs(data)
+	if err != nil {
+		return fmt.Errorf("invalid CustomResourceDefinitions in %s: %v", p.file, err)
+	}
+	return p.loadResources(resources)
+}
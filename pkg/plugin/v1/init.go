@@ -2,13 +2,13 @@ package v1
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/seamounts/kubeapi/internal/cmdutil"
 	"github.com/seamounts/kubeapi/pkg/internal/validation"
 	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/kubernetes"
 	"github.com/seamounts/kubeapi/pkg/plugin"
 	"github.com/spf13/pflag"
 
@@ -27,6 +27,12 @@ type initPlugin struct {
 
 	// flags
 	skipGoVersionCheck bool
+
+	// flags are the flags of the subcommand, to tell the ones set by users
+	flags *pflag.FlagSet
+	dryRun
+	runtime
+	templatesDir
 }
 
 var (
@@ -35,16 +41,38 @@ var (
 )
 
 func (p *initPlugin) UpdateContext(ctx *plugin.Context) {
-	ctx.Description = `Initialize a new project including vendor/ directory and Go package directories.
+	ctx.Description = fmt.Sprintf(`Initialize a new project including vendor/ directory and Go package directories.
 
 Writes the following files:
 - a boilerplate license file
 - a PROJECT file with the domain and repo
 - a go.mod with project dependencies
 
-`
+With --kubernetes-version, go.mod requires the versions of k8s.io/api, k8s.io/apimachinery, k8s.io/client-go
+and sigs.k8s.io/controller-runtime that match the targeted Kubernetes version, which is recorded in the
+PROJECT file so that the generated code is adapted to it. Supported versions: %s.
+
+With --client-style context, which requires Kubernetes 1.18 or newer, the methods of the generated clients
+take a context.Context first, and the create, update and patch options on the mutating calls. The style is
+recorded in the PROJECT file as clientStyle.
+
+With --registration apimachinery, the API types are registered in schemes with the runtime.SchemeBuilder of
+k8s.io/apimachinery instead of the scheme.Builder of controller-runtime, for the API packages and the generated
+clients not to depend on controller-runtime, which go.mod then does not require. The style is recorded in the
+PROJECT file as registration.
+
+`, strings.Join(kubernetes.SupportedVersions(), ", "))
 	ctx.Examples = fmt.Sprintf(`  # Scaffold a project using the apache2 license with "The Kubernetes authors" as owners
-  %s init --project-version=1 --domain example.org --license apache2 --owner "The Kubernetes authors"
+  %[1]s init --project-version=1 --domain example.org --license apache2 --owner "The Kubernetes authors"
+
+  # Scaffold a project whose generated code depends on the libraries of Kubernetes 1.28
+  %[1]s init --domain example.org --kubernetes-version 1.28
+
+  # Scaffold a project whose generated clients take a context.Context
+  %[1]s init --domain example.org --kubernetes-version 1.28 --client-style context
+
+  # Scaffold a project whose API types and clients do not depend on controller-runtime
+  %[1]s init --domain example.org --registration apimachinery
 `,
 		ctx.CommandName)
 
@@ -52,8 +80,12 @@ Writes the following files:
 }
 
 func (p *initPlugin) BindFlags(fs *pflag.FlagSet) {
+	p.flags = fs
+
 	fs.BoolVar(&p.skipGoVersionCheck, "skip-go-version-check",
 		false, "if specified, skip checking the Go version")
+	p.dryRun.bindFlag(fs)
+	p.templatesDir.bindFlag(fs)
 
 	// boilerplate args
 	fs.StringVar(&p.license, "license", "apache2",
@@ -64,6 +96,14 @@ func (p *initPlugin) BindFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&p.config.Repo, "repo", "", "name to use for go module (e.g., github.com/user/repo), "+
 		"defaults to the go package of the current working directory.")
 	fs.StringVar(&p.config.Domain, "domain", "my.domain", "domain for groups")
+	fs.StringVar(&p.config.KubernetesVersion, "kubernetes-version", "",
+		"Kubernetes minor version targeted by the project (e.g. 1.28), which selects the versions of its dependencies")
+	fs.StringVar(&p.config.ClientStyle, "client-style", "",
+		fmt.Sprintf("style of the generated clients, may be one of '%s' (used if empty), '%s'",
+			config.ClientStyleLegacy, config.ClientStyleContext))
+	fs.StringVar(&p.config.Registration, "registration", "",
+		fmt.Sprintf("style of the registration of the API types in schemes, may be one of '%s' (used if empty), '%s'",
+			config.RegistrationControllerRuntime, config.RegistrationAPIMachinery))
 }
 
 func (p *initPlugin) InjectConfig(c *config.Config) {
@@ -71,7 +111,7 @@ func (p *initPlugin) InjectConfig(c *config.Config) {
 }
 
 func (p *initPlugin) Run() error {
-	return cmdutil.Run(p)
+	return cmdutil.Run(p, p.projectRuntime())
 }
 
 func (p *initPlugin) Validate() error {
@@ -83,18 +123,33 @@ func (p *initPlugin) Validate() error {
 	}
 
 	// Check if the project name is a valid namespace according to k8s
-	dir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("error to get the current path: %v", err)
-	}
+	dir := p.projectRuntime().ProjectRoot
 	projectName := filepath.Base(dir)
 	if err := validation.IsDNS1123Label(strings.ToLower(projectName)); err != nil {
 		return fmt.Errorf("project name (%s) is invalid: %v", projectName, err)
 	}
 
+	if err := p.promptProject(dir); err != nil {
+		return err
+	}
+
+	if p.config.KubernetesVersion != "" {
+		release, err := kubernetes.Lookup(p.config.KubernetesVersion)
+		if err != nil {
+			return err
+		}
+		p.config.KubernetesVersion = release.Version
+	}
+	if err := p.config.ValidateClientStyle(); err != nil {
+		return err
+	}
+	if err := p.config.ValidateRegistration(); err != nil {
+		return err
+	}
+
 	// Try to guess repository if flag is not set.
 	if p.config.Repo == "" {
-		repoPath, err := internal.FindCurrentRepo()
+		repoPath, err := internal.FindCurrentRepo(dir)
 		if err != nil {
 			return fmt.Errorf("error finding current repository: %v", err)
 		}
@@ -104,11 +159,62 @@ func (p *initPlugin) Validate() error {
 	return nil
 }
 
+// promptProject asks users for the repo of the project rooted at dir, and for its domain and Kubernetes version that
+// are not set with flags, if the runtime is interactive and the repo is not set with flags
+func (p *initPlugin) promptProject(dir string) error {
+	prompter := p.prompter()
+	if prompter == nil || p.config.Repo != "" {
+		return nil
+	}
+
+	// The repo found from the go module or the GOPATH is the default one
+	repoPath, _ := internal.FindCurrentRepo(dir)
+	repo, err := prompter.String("Repo (go module)", repoPath, func(repo string) error {
+		if repo == "" || strings.ContainsAny(repo, " \t") {
+			return fmt.Errorf("repo must be a go package path")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	p.config.Repo = repo
+
+	if !p.flags.Changed("domain") {
+		domain, err := prompter.String("Domain", p.config.Domain, func(domain string) error {
+			if err := validation.IsDNS1123Subdomain(domain); err != nil {
+				return fmt.Errorf("domain is invalid: (%v)", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		p.config.Domain = domain
+	}
+
+	if p.config.KubernetesVersion == "" {
+		version, err := prompter.String(fmt.Sprintf("Kubernetes version (%s), empty for none",
+			strings.Join(kubernetes.SupportedVersions(), ", ")), "", func(version string) error {
+			if version == "" {
+				return nil
+			}
+			_, err := kubernetes.Lookup(version)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		p.config.KubernetesVersion = version
+	}
+	return nil
+}
+
 func (p *initPlugin) GetScaffolder() (scaffold.Scaffolder, error) {
-	return scaffold.NewInitScaffolder(p.config, p.license, p.owner), nil
+	return scaffold.NewInitScaffolder(p.config, p.license, p.owner, p.scaffoldOptions(p.templatesOption())...), nil
 }
 
 func (p *initPlugin) PostScaffold() error {
-	fmt.Printf("Next: define a resource with:\n$ %s create api\n", p.commandName)
+	fmt.Fprintf(p.stdout(), "Next: define a resource with:\n$ %s create api\n", p.commandName)
 	return nil
 }
@@ -29,18 +29,37 @@ const (
 var supportedProjectVersions = []string{config.Version1}
 
 var (
-	_ plugin.Base                  = Plugin{}
-	_ plugin.InitPluginGetter      = Plugin{}
-	_ plugin.CreateAPIPluginGetter = Plugin{}
+	_ plugin.Base                       = Plugin{}
+	_ plugin.InitPluginGetter           = Plugin{}
+	_ plugin.CreateAPIPluginGetter      = Plugin{}
+	_ plugin.CreateAPIsPluginGetter     = Plugin{}
+	_ plugin.RenameKindPluginGetter     = Plugin{}
+	_ plugin.MoveKindPluginGetter       = Plugin{}
+	_ plugin.ImportCRDPluginGetter      = Plugin{}
+	_ plugin.GenerateClientPluginGetter = Plugin{}
+
+	_ plugin.ExportTemplatesPluginGetter = Plugin{}
 )
 
 type Plugin struct {
 	initPlugin
 	createAPIPlugin
+	createAPIsPlugin
+	renameKindPlugin
+	moveKindPlugin
+	importCRDPlugin
+	generateClientPlugin
+	exportTemplatesPlugin
 }
 
-func (Plugin) Name() string                           { return pluginName }
-func (Plugin) Version() string                        { return pluginVersion }
-func (Plugin) SupportedProjectVersions() []string     { return supportedProjectVersions }
-func (p Plugin) GetInitPlugin() plugin.Init           { return &p.initPlugin }
-func (p Plugin) GetCreateAPIPlugin() plugin.CreateAPI { return &p.createAPIPlugin }
+func (Plugin) Name() string                                       { return pluginName }
+func (Plugin) Version() string                                    { return pluginVersion }
+func (Plugin) SupportedProjectVersions() []string                 { return supportedProjectVersions }
+func (p Plugin) GetInitPlugin() plugin.Init                       { return &p.initPlugin }
+func (p Plugin) GetCreateAPIPlugin() plugin.CreateAPI             { return &p.createAPIPlugin }
+func (p Plugin) GetCreateAPIsPlugin() plugin.CreateAPIs           { return &p.createAPIsPlugin }
+func (p Plugin) GetRenameKindPlugin() plugin.RenameKind           { return &p.renameKindPlugin }
+func (p Plugin) GetMoveKindPlugin() plugin.MoveKind               { return &p.moveKindPlugin }
+func (p Plugin) GetImportCRDPlugin() plugin.ImportCRD             { return &p.importCRDPlugin }
+func (p Plugin) GetGenerateClientPlugin() plugin.GenerateClient   { return &p.generateClientPlugin }
+func (p Plugin) GetExportTemplatesPlugin() plugin.ExportTemplates { return &p.exportTemplatesPlugin }
@@ -0,0 +1,469 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/seamounts/kubeapi/pkg/cli"
+	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+	pluginv1 "github.com/seamounts/kubeapi/pkg/plugin/v1"
+	"github.com/seamounts/kubeapi/pkg/scaffoldtest"
+)
+
+func newProject() *scaffoldtest.Project {
+	return scaffoldtest.NewProject(
+		cli.WithPlugins(&pluginv1.Plugin{}),
+		cli.WithDefaultPlugin(&pluginv1.Plugin{}),
+	)
+}
+
+func initProject(t *testing.T, p *scaffoldtest.Project) *scaffoldtest.Result {
+	t.Helper()
+	res, err := p.Run("init", "--repo", "example.com/project", "--domain", "example.com", "--skip-go-version-check")
+	if err != nil {
+		t.Fatalf("init failed: %v\n%s", err, res.Output)
+	}
+	return res
+}
+
+func TestInit(t *testing.T) {
+	res := initProject(t, newProject())
+	scaffoldtest.AssertGolden(t, filepath.Join("testdata", "init"), res.Files)
+}
+
+func TestCreateAPI(t *testing.T) {
+	p := newProject()
+	initProject(t, p)
+
+	res, err := p.Run("create", "api", "--group", "ship", "--version", "v1beta1", "--kind", "Frigate",
+		"--generate=false")
+	if err != nil {
+		t.Fatalf("create api failed: %v\n%s", err, res.Output)
+	}
+	scaffoldtest.AssertGolden(t, filepath.Join("testdata", "create-api"), res.Files)
+
+	res, err = p.Run("create", "api", "--group", "ship", "--version", "v1beta1", "--kind", "Frigate",
+		"--generate=false")
+	if err == nil || !strings.Contains(res.Output, "already exists") {
+		t.Errorf("unexpected result of creating the API twice: %v\n%s", err, res.Output)
+	}
+}
+
+func TestCreateAPIDryRun(t *testing.T) {
+	p := newProject()
+	before := initProject(t, p)
+
+	res, err := p.Run("create", "api", "--group", "ship", "--version", "v1beta1", "--kind", "Frigate", "--dry-run")
+	if err != nil {
+		t.Fatalf("create api failed: %v\n%s", err, res.Output)
+	}
+	scaffoldtest.AssertGolden(t, filepath.Join("testdata", "init"), res.Files)
+	if res.Config != before.Config {
+		t.Errorf("dry run saved the PROJECT file:\n%s", res.Config)
+	}
+}
+
+func TestCreateAPIJSONOutput(t *testing.T) {
+	p := newProject()
+	initProject(t, p)
+
+	res, err := p.Run("create", "api", "--group", "ship", "--version", "v1beta1", "--kind", "Frigate",
+		"--generate=false", "--output", "json")
+	if err != nil {
+		t.Fatalf("create api failed: %v\n%s", err, res.Output)
+	}
+	var result cli.Result
+	if err := json.Unmarshal([]byte(res.Output), &result); err != nil {
+		t.Fatalf("invalid output: %v\n%s", err, res.Output)
+	}
+	if result.Command != "kubeapi create api" || result.Error != nil {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(result.Resources) != 1 || result.Resources[0].Kind != "Frigate" {
+		t.Errorf("unexpected resources: %+v", result.Resources)
+	}
+	actions := make(map[string]file.Action, len(result.Files))
+	for _, change := range result.Files {
+		actions[change.Path] = change.Action
+	}
+	if actions["apis/ship/v1beta1/frigate_types.go"] != file.Created {
+		t.Errorf("unexpected files: %+v", result.Files)
+	}
+
+	res, err = p.Run("init", "--repo", "example.com/project", "--output", "json")
+	if err == nil {
+		t.Fatal("init of an initialized project succeeded")
+	}
+	result = cli.Result{}
+	if err := json.Unmarshal([]byte(res.Output), &result); err != nil {
+		t.Fatalf("invalid output: %v\n%s", err, res.Output)
+	}
+	if result.Error == nil || result.Error.Code != cli.ErrorCodeAlreadyInitialized {
+		t.Errorf("unexpected error: %+v", result.Error)
+	}
+}
+
+func TestCreateAPIMergeConflict(t *testing.T) {
+	p := newProject()
+	initProject(t, p)
+
+	res, err := p.Run("create", "api", "--group", "ship", "--version", "v1beta1", "--kind", "Frigate",
+		"--generate=false")
+	if err != nil {
+		t.Fatalf("create api failed: %v\n%s", err, res.Output)
+	}
+
+	// The example field is replaced both by the user and by the new scaffold
+	const path = "apis/ship/v1beta1/frigate_types.go"
+	edited := strings.Replace(res.Files[path], "Foo string `json:\"foo,omitempty\"`",
+		"Replicas int32 `json:\"replicas\"`", 1)
+	if err := afero.WriteFile(p.FS(), path, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = p.Run("create", "api", "--group", "ship", "--version", "v1beta1", "--kind", "Frigate",
+		"--generate=false", "--force", "--spec-field", "captain:string", "--output", "json")
+	if err == nil {
+		t.Fatalf("create api with a merge conflict succeeded:\n%s", res.Output)
+	}
+	var result cli.Result
+	if err := json.Unmarshal([]byte(res.Output), &result); err != nil {
+		t.Fatalf("invalid output: %v\n%s", err, res.Output)
+	}
+	if result.Error == nil || result.Error.Code != cli.ErrorCodeMergeConflict {
+		t.Errorf("unexpected error: %+v", result.Error)
+	}
+	actions := make(map[string]file.Action, len(result.Files))
+	for _, change := range result.Files {
+		actions[change.Path] = change.Action
+	}
+	if actions[path] != file.Updated {
+		t.Errorf("unexpected files: %+v", result.Files)
+	}
+
+	// The conflict markers are left in the project for the user to resolve them
+	for _, expected := range []string{"<<<<<<< ", "Replicas int32", "=======", "Captain string", ">>>>>>> "} {
+		if !strings.Contains(res.Files[path], expected) {
+			t.Errorf("the merged file does not contain %q:\n%s", expected, res.Files[path])
+		}
+	}
+	if _, found := res.Files[".kubeapi/base/"+path]; !found {
+		t.Error("the base of the merged file was removed")
+	}
+	for name := range res.Files {
+		if strings.HasPrefix(name, ".kubeapi/transaction-") {
+			t.Errorf("the transaction was not committed: %s exists", name)
+		}
+	}
+}
+
+func TestCreateAPIDryRunMergeConflict(t *testing.T) {
+	p := newProject()
+	initProject(t, p)
+
+	res, err := p.Run("create", "api", "--group", "ship", "--version", "v1beta1", "--kind", "Frigate",
+		"--generate=false")
+	if err != nil {
+		t.Fatalf("create api failed: %v\n%s", err, res.Output)
+	}
+
+	// The example field is replaced both by the user and by the new scaffold
+	const path = "apis/ship/v1beta1/frigate_types.go"
+	edited := strings.Replace(res.Files[path], "Foo string `json:\"foo,omitempty\"`",
+		"Replicas int32 `json:\"replicas\"`", 1)
+	if err := afero.WriteFile(p.FS(), path, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = p.Run("create", "api", "--group", "ship", "--version", "v1beta1", "--kind", "Frigate",
+		"--generate=false", "--force", "--spec-field", "captain:string", "--dry-run")
+	if err == nil || !strings.Contains(res.Output, "failed to merge "+path) {
+		t.Errorf("unexpected result of a dry run with a merge conflict: %v\n%s", err, res.Output)
+	}
+	// The diff of the merged file shows the conflict, which is not written
+	for _, expected := range []string{"--- a/" + path, "+<<<<<<< ", "+=======", "+>>>>>>> "} {
+		if !strings.Contains(res.Output, expected) {
+			t.Errorf("the output does not contain %q:\n%s", expected, res.Output)
+		}
+	}
+	if res.Files[path] != edited {
+		t.Errorf("the dry run changed %s:\n%s", path, res.Files[path])
+	}
+}
+
+// presetPlugin is the v1 plugin with a preset of its own
+type presetPlugin struct {
+	pluginv1.Plugin
+}
+
+// Presets implements plugin.PresetProvider
+func (presetPlugin) Presets() []resource.Preset {
+	return []resource.Preset{{
+		Name:       "warship",
+		SpecFields: []resource.Field{{Name: "guns", Type: "int32", Required: true}},
+		NoStatus:   true,
+	}}
+}
+
+func TestCreateAPIPluginPreset(t *testing.T) {
+	p := scaffoldtest.NewProject(
+		cli.WithPlugins(&presetPlugin{}),
+		cli.WithDefaultPlugin(&presetPlugin{}),
+	)
+	initProject(t, p)
+
+	res, err := p.Run("create", "api", "--group", "ship", "--version", "v1beta1", "--kind", "Frigate",
+		"--preset", "warship", "--spec-field", "captain:string", "--generate=false")
+	if err != nil {
+		t.Fatalf("create api failed: %v\n%s", err, res.Output)
+	}
+	types := res.Files["apis/ship/v1beta1/frigate_types.go"]
+	for _, expected := range []string{"// +genclient:noStatus", "\tGuns int32 `json:\"guns\"`", "\tCaptain string"} {
+		if !strings.Contains(types, expected) {
+			t.Errorf("the types file does not contain %q:\n%s", expected, types)
+		}
+	}
+
+	res, err = p.Run("create", "api", "--group", "ship", "--version", "v1beta1", "--kind", "Sloop",
+		"--preset", "battleship", "--generate=false")
+	if err == nil || !strings.Contains(res.Output, `unknown preset "battleship"`) {
+		t.Errorf("unexpected result of an unknown preset: %v\n%s", err, res.Output)
+	}
+}
+
+func TestImportCRD(t *testing.T) {
+	p := newProject()
+	initProject(t, p)
+
+	crds, err := ioutil.ReadFile(filepath.Join("testdata", "crds", "frigates.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(p.FS(), "crds.yaml", crds, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := p.Run("import", "crd", "-f", "crds.yaml", "--generate=false")
+	if err != nil {
+		t.Fatalf("import crd failed: %v\n%s", err, res.Output)
+	}
+	// The imported file is not scaffolded
+	delete(res.Files, "crds.yaml")
+	scaffoldtest.AssertGolden(t, filepath.Join("testdata", "import-crd"), res.Files)
+
+	res, err = p.Run("import", "crd", "-f", "crds.yaml", "--generate=false")
+	if err == nil || !strings.Contains(res.Output, "already exists") {
+		t.Errorf("unexpected result of importing the CRDs twice: %v\n%s", err, res.Output)
+	}
+}
+
+func TestCreateAPIs(t *testing.T) {
+	p := newProject()
+	initProject(t, p)
+
+	apis, err := ioutil.ReadFile(filepath.Join("testdata", "apis", "apis.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(p.FS(), "apis.yaml", apis, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := p.Run("create", "apis", "-f", "apis.yaml", "--generate=false")
+	if err != nil {
+		t.Fatalf("create apis failed: %v\n%s", err, res.Output)
+	}
+	// The file of the resources is not scaffolded
+	delete(res.Files, "apis.yaml")
+	scaffoldtest.AssertGolden(t, filepath.Join("testdata", "create-apis"), res.Files)
+
+	if err := afero.WriteFile(p.FS(), "invalid.yaml", []byte(`resources:
+- {group: ship, version: v1, kind: Corvette}
+- {group: ship, version: v1, kind: sloop}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = p.Run("create", "apis", "-f", "invalid.yaml", "--generate=false")
+	if err == nil || !strings.Contains(res.Output, "invalid resource #2") {
+		t.Errorf("unexpected result of creating an invalid resource: %v\n%s", err, res.Output)
+	}
+	// Every resource is validated before any file is scaffolded
+	if _, found := res.Files["apis/ship/v1/corvette_types.go"]; found {
+		t.Error("the valid resource was scaffolded along with the invalid one")
+	}
+}
+
+func TestGenerateClientValidation(t *testing.T) {
+	p := newProject()
+	initProject(t, p)
+
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{nil, "no client is recorded"},
+		{[]string{"--input-package", "github.com/acme/apis/foo/v1"}, "output directory of the client is required"},
+		{[]string{"--input-package", "github.com/acme/apis/foo/v1", "--output-dir", "apis/acme"}, "must not be under apis/"},
+		{[]string{"--input-package", "github.com/acme/apis/foo/v1", "--output-dir", "client"}, "client of the project"},
+		{[]string{"--input-package", "github.com/acme/apis/foo", "--output-dir", "client/acme"}, "version must match"},
+	} {
+		res, err := p.Run(append([]string{"generate", "client"}, tc.args...)...)
+		if err == nil || !strings.Contains(res.Output, tc.err) {
+			t.Errorf("generate client %v: expected an error with %q, got %v\n%s", tc.args, tc.err, err, res.Output)
+		}
+	}
+}
+
+func TestCreateAPIPrompts(t *testing.T) {
+	p := newProject()
+	initProject(t, p)
+
+	// The lowercase kind is asked again, the default plural is kept
+	answers := "ship\nv1\nfrigate\nFrigate\nCluster\n\ny\nn\n"
+	res, err := p.RunInteractive(answers, "create", "api", "--generate=false")
+	if err != nil {
+		t.Fatalf("create api failed: %v\n%s", err, res.Output)
+	}
+	if !strings.Contains(res.Output, "Invalid answer: invalid Kind") {
+		t.Errorf("the invalid kind was not reported:\n%s", res.Output)
+	}
+	types := res.Files["apis/ship/v1/frigate_types.go"]
+	for _, marker := range []string{
+		"// +genclient:nonNamespaced\n",
+		"// +kubebuilder:subresource:status\n",
+		"// +kubebuilder:resource:scope=Cluster\n",
+	} {
+		if !strings.Contains(types, marker) {
+			t.Errorf("missing %q in the types:\n%s", marker, types)
+		}
+	}
+
+	res, err = p.RunInteractive(answers, "create", "api", "--generate=false", "--no-interactive")
+	if err == nil || !strings.Contains(res.Output, "group cannot be empty") {
+		t.Errorf("unexpected result without prompts: %v\n%s", err, res.Output)
+	}
+
+	// Fully specified commands do not prompt, so they need no answers
+	res, err = p.RunInteractive("", "create", "api", "--generate=false",
+		"--group", "ship", "--version", "v1", "--kind", "Destroyer")
+	if err != nil {
+		t.Fatalf("create api with flags failed: %v\n%s", err, res.Output)
+	}
+	if types := res.Files["apis/ship/v1/destroyer_types.go"]; strings.Contains(types, "+kubebuilder:resource:") {
+		t.Errorf("unexpected resource marker in the types:\n%s", types)
+	}
+}
+
+func TestListPlugins(t *testing.T) {
+	p := newProject()
+	initProject(t, p)
+
+	res, err := p.Run("plugins", "list")
+	if err != nil {
+		t.Fatalf("plugins list failed: %v\n%s", err, res.Output)
+	}
+	lines := strings.Split(strings.TrimSpace(res.Output), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "KEY ") {
+		t.Fatalf("unexpected output:\n%s", res.Output)
+	}
+	// The plugin of the project is marked, with the subcommands it implements and its source
+	fields := strings.Fields(lines[1])
+	if fields[0] != "go.kubeapi.io/v1.0.0" || fields[1] != "*" || fields[2] != "1" {
+		t.Errorf("unexpected plugin line %q", lines[1])
+	}
+	for _, expected := range []string{"init, create api, create apis,", "built-in (default)"} {
+		if !strings.Contains(lines[1], expected) {
+			t.Errorf("the plugin line does not contain %q: %q", expected, lines[1])
+		}
+	}
+
+	res, err = p.Run("plugins", "list", "--output", "json")
+	if err != nil {
+		t.Fatalf("plugins list failed: %v\n%s", err, res.Output)
+	}
+	var result cli.Result
+	if err := json.Unmarshal([]byte(res.Output), &result); err != nil {
+		t.Fatalf("invalid output: %v\n%s", err, res.Output)
+	}
+	if result.Command != "kubeapi plugins list" || len(result.AvailablePlugins) != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	info := result.AvailablePlugins[0]
+	if info.Key != "go.kubeapi.io/v1.0.0" || !info.Selected || info.Source != "built-in (default)" ||
+		!reflect.DeepEqual(info.ProjectVersions, []string{"1"}) || len(info.Subcommands) == 0 ||
+		info.Subcommands[0] != "init" {
+		t.Errorf("unexpected plugin: %+v", info)
+	}
+}
+
+func TestDescribePlugin(t *testing.T) {
+	p := newProject()
+	initProject(t, p)
+
+	// The plugin is selected by its short name
+	res, err := p.Run("plugins", "describe", "go")
+	if err != nil {
+		t.Fatalf("plugins describe failed: %v\n%s", err, res.Output)
+	}
+	for _, expected := range []string{
+		"Plugin: go.kubeapi.io/v1.0.0\nSource: built-in (default)\nSupported project versions: 1\n",
+		"\nkubeapi init\n\nInitialize a new project",
+		"\nkubeapi create api\n\nScaffold a Kubernetes API",
+		"\nExamples:\n",
+		"\nFlags:\n",
+		"--skip-go-version-check",
+		"--spec-field",
+	} {
+		if !strings.Contains(res.Output, expected) {
+			t.Errorf("the output does not contain %q:\n%s", expected, res.Output)
+		}
+	}
+
+	res, err = p.Run("plugins", "describe", "go.kubeapi.io/v1.0.0", "--output", "json")
+	if err != nil {
+		t.Fatalf("plugins describe failed: %v\n%s", err, res.Output)
+	}
+	var result cli.Result
+	if err := json.Unmarshal([]byte(res.Output), &result); err != nil {
+		t.Fatalf("invalid output: %v\n%s", err, res.Output)
+	}
+	if result.Command != "kubeapi plugins describe" || len(result.AvailablePlugins) != 1 ||
+		result.AvailablePlugins[0].Key != "go.kubeapi.io/v1.0.0" || !result.AvailablePlugins[0].Selected {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	res, err = p.Run("plugins", "describe", "unknown", "--output", "json")
+	if err == nil {
+		t.Fatal("describing an unknown plugin succeeded")
+	}
+	result = cli.Result{}
+	if err := json.Unmarshal([]byte(res.Output), &result); err != nil {
+		t.Fatalf("invalid output: %v\n%s", err, res.Output)
+	}
+	if result.Error == nil || result.Error.Message != `plugin "unknown" not found` {
+		t.Errorf("unexpected error: %+v", result.Error)
+	}
+}
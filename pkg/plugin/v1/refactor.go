@@ -0,0 +1,251 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gobuffalo/flect"
+	"github.com/seamounts/kubeapi/internal/cmdutil"
+	"github.com/seamounts/kubeapi/pkg/codegen"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+	"github.com/seamounts/kubeapi/pkg/scaffold"
+	"github.com/spf13/pflag"
+)
+
+type renameKindPlugin struct {
+	config *config.Config
+
+	resource *resource.Options
+
+	// toKind is the new Kind of the resource
+	toKind string
+
+	dryRun
+	runtime
+}
+
+var (
+	_ plugin.RenameKind  = &renameKindPlugin{}
+	_ cmdutil.RunOptions = &renameKindPlugin{}
+)
+
+func (p renameKindPlugin) UpdateContext(ctx *plugin.Context) {
+	ctx.Description = `Rename the Kind of an existing API.
+
+Every top-level declaration of the API group-version named after the Kind (e.g. Frigate, FrigateSpec,
+FrigateStatus, FrigateList) is renamed, along with the types file and every qualified reference to them
+in the project. The declarations of the other Kinds of the group-version (e.g. FrigateClass) are kept. The PROJECT file is updated and the deepcopy funcs, clientset, listers and informers
+are regenerated.
+`
+	ctx.Examples = fmt.Sprintf(`  # Rename the Frigate Kind of Group: ship, Version: v1beta1 to Cruiser
+  %s refactor rename-kind --group ship --version v1beta1 --kind Frigate --to-kind Cruiser
+`,
+		ctx.CommandName)
+}
+
+func (p *renameKindPlugin) BindFlags(fs *pflag.FlagSet) {
+	p.resource = &resource.Options{}
+	fs.StringVar(&p.resource.Kind, "kind", "", "resource Kind")
+	fs.StringVar(&p.resource.Group, "group", "", "resource Group")
+	fs.StringVar(&p.resource.Version, "version", "", "resource Version")
+
+	fs.StringVar(&p.toKind, "to-kind", "", "new resource Kind")
+
+	p.dryRun.bindFlag(fs)
+}
+
+func (p *renameKindPlugin) InjectConfig(c *config.Config) {
+	p.config = c
+}
+
+func (p *renameKindPlugin) Run() error {
+	return cmdutil.Run(p, p.projectRuntime())
+}
+
+func (p *renameKindPlugin) Validate() error {
+	if err := p.resource.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.toResource().Validate(); err != nil {
+		return fmt.Errorf("invalid new kind: %v", err)
+	}
+
+	if p.toKind == p.resource.Kind {
+		return errors.New("new kind must be different from the current one")
+	}
+
+	return nil
+}
+
+func (p *renameKindPlugin) GetScaffolder() (scaffold.Scaffolder, error) {
+	return scaffold.NewRenameKindScaffolder(p.config,
+		projectResource(p.config, p.resource), projectResource(p.config, p.toResource()), p.scaffoldOptions()...), nil
+}
+
+func (p *renameKindPlugin) PostScaffold() error {
+	gvk := refactoredGVK(p.config, p.resource, p.toResource())
+	p.config.UpdateResource(p.resource.GVK(), gvk)
+	p.recordResource(gvk)
+
+	return regenerate(p.config, p.projectRuntime())
+}
+
+// toResource returns the options of the renamed resource
+func (p *renameKindPlugin) toResource() *resource.Options {
+	to := *p.resource
+	to.Kind = p.toKind
+	return &to
+}
+
+type moveKindPlugin struct {
+	config *config.Config
+
+	resource *resource.Options
+
+	// toGroup and toVersion are the group-version the resource is moved to
+	toGroup   string
+	toVersion string
+
+	dryRun
+	runtime
+	templatesDir
+}
+
+var (
+	_ plugin.MoveKind    = &moveKindPlugin{}
+	_ cmdutil.RunOptions = &moveKindPlugin{}
+)
+
+func (p moveKindPlugin) UpdateContext(ctx *plugin.Context) {
+	ctx.Description = `Move the Kind of an existing API to another group and / or version.
+
+The types file of the Kind is moved to the new group-version, which is scaffolded if it does not exist,
+and every qualified reference to its declarations in the project is updated to import the new package.
+The PROJECT file is updated and the deepcopy funcs, clientset, listers and informers are regenerated.
+
+Declarations of the old group-version that are not part of the types file are not moved.
+`
+	ctx.Examples = fmt.Sprintf(`  # Move the Frigate Kind of Group: ship, Version: v1beta1 to Group: fleet, Version: v1
+  %s refactor move-kind --group ship --version v1beta1 --kind Frigate --to-group fleet --to-version v1
+`,
+		ctx.CommandName)
+}
+
+func (p *moveKindPlugin) BindFlags(fs *pflag.FlagSet) {
+	p.resource = &resource.Options{}
+	fs.StringVar(&p.resource.Kind, "kind", "", "resource Kind")
+	fs.StringVar(&p.resource.Group, "group", "", "resource Group")
+	fs.StringVar(&p.resource.Version, "version", "", "resource Version")
+
+	fs.StringVar(&p.toGroup, "to-group", "", "new resource Group, defaults to the current one")
+	fs.StringVar(&p.toVersion, "to-version", "", "new resource Version, defaults to the current one")
+
+	p.dryRun.bindFlag(fs)
+	p.templatesDir.bindFlag(fs)
+}
+
+func (p *moveKindPlugin) InjectConfig(c *config.Config) {
+	p.config = c
+}
+
+func (p *moveKindPlugin) Run() error {
+	return cmdutil.Run(p, p.projectRuntime())
+}
+
+func (p *moveKindPlugin) Validate() error {
+	if err := p.resource.Validate(); err != nil {
+		return err
+	}
+
+	if p.toGroup == "" && p.toVersion == "" {
+		return errors.New("at least one of --to-group and --to-version is required")
+	}
+
+	to := p.toResource()
+	if err := to.Validate(); err != nil {
+		return fmt.Errorf("invalid new group-version: %v", err)
+	}
+
+	if to.GVK() == p.resource.GVK() {
+		return errors.New("new group-version must be different from the current one")
+	}
+
+	return nil
+}
+
+func (p *moveKindPlugin) GetScaffolder() (scaffold.Scaffolder, error) {
+	// Load the boilerplate
+	bp, err := p.readBoilerplate()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load boilerplate: %v", err)
+	}
+
+	return scaffold.NewMoveKindScaffolder(p.config, bp,
+		projectResource(p.config, p.resource), projectResource(p.config, p.toResource()),
+		p.scaffoldOptions(p.templatesOption())...), nil
+}
+
+func (p *moveKindPlugin) PostScaffold() error {
+	gvk := refactoredGVK(p.config, p.resource, p.toResource())
+	p.config.UpdateResource(p.resource.GVK(), gvk)
+	p.recordResource(gvk)
+
+	return regenerate(p.config, p.projectRuntime())
+}
+
+// toResource returns the options of the moved resource
+func (p *moveKindPlugin) toResource() *resource.Options {
+	to := *p.resource
+	if p.toGroup != "" {
+		to.Group = p.toGroup
+	}
+	if p.toVersion != "" {
+		to.Version = p.toVersion
+	}
+	return &to
+}
+
+// projectResource returns the resource of opts in the apis of the project, without looking up the package of
+// the core groups, since the kinds are refactored from and to the project group-versions
+func projectResource(c *config.Config, opts *resource.Options) *resource.Resource {
+	res := opts.NewResource(c)
+	res.Package = path.Join(c.Repo, "apis", res.Group, res.Version)
+	res.Domain = res.Group
+	if c.Domain != "" {
+		res.Domain += "." + c.Domain
+	}
+	return res
+}
+
+// refactoredGVK returns the GVK to track the resource from is refactored to with, keeping the plural and the scope
+// of the tracked one, except the plural of a renamed kind, which is derived from the new kind
+func refactoredGVK(c *config.Config, from, to *resource.Options) config.GVK {
+	gvk := to.GVK()
+	for _, r := range c.Resources {
+		if r.Group == from.Group && r.Version == from.Version && r.Kind == from.Kind {
+			gvk.Plural, gvk.Scope = r.Plural, r.Scope
+		}
+	}
+	if gvk.Plural != "" && to.Kind != from.Kind {
+		gvk.Plural = flect.Pluralize(strings.ToLower(to.Kind))
+	}
+	return gvk
+}
+
+// regenerate runs the code generators for every group-version of the project
+func regenerate(c *config.Config, rt plugin.Runtime) error {
+	rt.Logger.Info("Start Generating Client")
+	gen, err := codegen.NewProjectCodeGen(c, rt.FS)
+	if err != nil {
+		return err
+	}
+	gen.InjectProjectRoot(rt.ProjectRoot)
+	gen.InjectRecord(rt.RecordGenerator)
+	gen.InjectLogger(rt.Logger)
+	return gen.Run()
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+)
+
+func TestRefactoredGVK(t *testing.T) {
+	c := &config.Config{Resources: []config.GVK{
+		{Group: "ship", Version: "v1beta1", Kind: "Frigate", Plural: "frigates", Scope: clusterScope},
+		{Group: "ship", Version: "v1beta1", Kind: "Sloop"},
+	}}
+	frigate := &resource.Options{Group: "ship", Version: "v1beta1", Kind: "Frigate"}
+
+	for name, test := range map[string]struct {
+		from     *resource.Options
+		to       *resource.Options
+		expected config.GVK
+	}{
+		"renamed kind": {
+			from:     frigate,
+			to:       &resource.Options{Group: "ship", Version: "v1beta1", Kind: "Ferry"},
+			expected: config.GVK{Group: "ship", Version: "v1beta1", Kind: "Ferry", Plural: "ferries", Scope: clusterScope},
+		},
+		"moved kind": {
+			from:     frigate,
+			to:       &resource.Options{Group: "fleet", Version: "v1", Kind: "Frigate"},
+			expected: config.GVK{Group: "fleet", Version: "v1", Kind: "Frigate", Plural: "frigates", Scope: clusterScope},
+		},
+		"kind tracked without plural": {
+			from:     &resource.Options{Group: "ship", Version: "v1beta1", Kind: "Sloop"},
+			to:       &resource.Options{Group: "ship", Version: "v1beta1", Kind: "Yacht"},
+			expected: config.GVK{Group: "ship", Version: "v1beta1", Kind: "Yacht"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if gvk := refactoredGVK(c, test.from, test.to); gvk != test.expected {
+				t.Errorf("refactoredGVK() = %+v, want %+v", gvk, test.expected)
+			}
+		})
+	}
+}
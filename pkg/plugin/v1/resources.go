@@ -0,0 +1,132 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/seamounts/kubeapi/pkg/codegen"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+	"github.com/seamounts/kubeapi/pkg/scaffold"
+)
+
+const (
+	namespacedScope = "Namespaced"
+	clusterScope    = "Cluster"
+)
+
+// fileResources scaffolds, tracks and generates the resources of a file for the subcommands that load them, e.g.
+// import crd, which implement the Validate of cmdutil.RunOptions by loading them with loadResources
+type fileResources struct {
+	config *config.Config
+
+	// file is the YAML file of the resources, relative to the project root
+	file string
+
+	// force indicates that the resources should be scaffolded even if they already exist
+	force bool
+
+	// generate indicates that the code generators should run after scaffolding the files
+	generate bool
+
+	// resources are the options of the resources of the file, loaded by Validate
+	resources []resource.Options
+
+	// newResources are the resources created from the options before they are tracked, which changes the
+	// package of the resources of the core groups
+	newResources []*resource.Resource
+
+	dryRun
+	runtime
+	templatesDir
+}
+
+// bindFlags binds the flags of the subcommand, whose usages describe the resources of the file and what the
+// subcommand does with them, e.g. "CustomResourceDefinitions to import" and "import"
+func (r *fileResources) bindFlags(fs *pflag.FlagSet, file, verb string) {
+	fs.StringVarP(&r.file, "file", "f", "", "YAML file, relative to the project root, with the "+file)
+	fs.BoolVar(&r.force, "force", false,
+		fmt.Sprintf("attempt to %s the resources even if they already exist", verb))
+	fs.BoolVar(&r.generate, "generate", true,
+		"if true, run the code generators (deepcopy, clientset, listers and informers) after scaffolding the files")
+	r.dryRun.bindFlag(fs)
+	r.templatesDir.bindFlag(fs)
+}
+
+func (r *fileResources) InjectConfig(c *config.Config) {
+	r.config = c
+}
+
+// loadResources sets the resources, which must be valid, once each, and new unless force is set
+func (r *fileResources) loadResources(resources []resource.Options) error {
+	if err := r.config.ValidateRegistration(); err != nil {
+		return err
+	}
+
+	loaded := make(map[config.GVK]struct{}, len(resources))
+	for _, opts := range resources {
+		gvk := opts.GVK()
+		if err := opts.Validate(); err != nil {
+			return fmt.Errorf("invalid resource %s/%s, Kind=%s: %v", gvk.Group, gvk.Version, gvk.Kind, err)
+		}
+		if _, found := loaded[gvk]; found {
+			return fmt.Errorf("resource %s/%s, Kind=%s is defined twice", gvk.Group, gvk.Version, gvk.Kind)
+		}
+		loaded[gvk] = struct{}{}
+
+		// Check that resource doesn't exist or flag force was set
+		if !r.force && r.config.HasResource(gvk) {
+			return fmt.Errorf("API resource %s/%s, Kind=%s already exists", gvk.Group, gvk.Version, gvk.Kind)
+		}
+	}
+
+	r.resources = resources
+	return nil
+}
+
+func (r *fileResources) GetScaffolder() (scaffold.Scaffolder, error) {
+	// Load the boilerplate
+	bp, err := r.readBoilerplate()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load boilerplate: %v", err)
+	}
+
+	// Create the actual resources from the resource options
+	r.newResources = make([]*resource.Resource, 0, len(r.resources))
+	for i := range r.resources {
+		r.newResources = append(r.newResources, r.resources[i].NewResource(r.config))
+	}
+	return scaffold.NewAPIsScaffolder(r.config, bp, r.newResources, r.scaffoldOptions(r.templatesOption())...), nil
+}
+
+func (r *fileResources) PostScaffold() error {
+	for _, res := range r.newResources {
+		gvk := trackedGVK(res)
+		r.config.TrackResource(gvk)
+		r.recordResource(gvk)
+	}
+
+	if !r.generate {
+		return nil
+	}
+
+	r.logger().Info("Start Generating Client")
+	rt := r.projectRuntime()
+	gen := codegen.NewCodeGen(r.config, r.newResources...)
+	gen.InjectFS(rt.FS)
+	gen.InjectProjectRoot(rt.ProjectRoot)
+	gen.InjectRecord(rt.RecordGenerator)
+	gen.InjectLogger(rt.Logger)
+	return gen.Run()
+}
+
+// trackedGVK returns the GVK res is tracked with in the PROJECT file, with its plural and its scope
+func trackedGVK(res *resource.Resource) config.GVK {
+	gvk := config.GVK{Group: res.Group, Version: res.Version, Kind: res.Kind, Plural: res.Plural,
+		Scope: namespacedScope}
+	if !res.Namespaced {
+		gvk.Scope = clusterScope
+	}
+	return gvk
+}
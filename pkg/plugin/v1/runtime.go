@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/afero"
+
+	"github.com/seamounts/kubeapi/internal/prompt"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+	"github.com/seamounts/kubeapi/pkg/scaffold"
+)
+
+var _ plugin.HasRuntime = &runtime{}
+
+// runtime implements plugin.HasRuntime for the subcommands that run through cmdutil. Without an injected
+// runtime, the subcommands run in the working directory of the process.
+type runtime struct {
+	rt plugin.Runtime
+
+	// prompts asks users for the information missing from the flags, see prompter
+	prompts *prompt.Prompter
+}
+
+// InjectRuntime implements plugin.HasRuntime
+func (r *runtime) InjectRuntime(rt plugin.Runtime) {
+	r.rt = rt
+}
+
+// projectRuntime returns the injected runtime, with the unset fields defaulting to the ones of the project in the
+// working directory of the process
+func (r runtime) projectRuntime() plugin.Runtime {
+	// The defaults can only be missing if the working directory cannot be found, like the project
+	rt, _ := r.rt.WithDefaults("")
+	return rt
+}
+
+// prompter returns the prompter of the subcommand, or nil if the runtime is not interactive
+func (r *runtime) prompter() *prompt.Prompter {
+	if !r.rt.Interactive {
+		return nil
+	}
+	if r.prompts == nil {
+		rt := r.projectRuntime()
+		r.prompts = prompt.New(rt.Stdin, rt.Stdout)
+	}
+	return r.prompts
+}
+
+// projectFs returns the file system of the project
+func (r runtime) projectFs() afero.Fs {
+	return r.projectRuntime().FS
+}
+
+// stdout returns where the messages for users are written
+func (r runtime) stdout() io.Writer {
+	return r.projectRuntime().Stdout
+}
+
+// logger returns the logger of the subcommand
+func (r runtime) logger() logr.Logger {
+	return r.projectRuntime().Logger
+}
+
+// recordResource records the resource the subcommand created, renamed or moved
+func (r runtime) recordResource(gvk config.GVK) {
+	if r.rt.RecordResource != nil {
+		r.rt.RecordResource(gvk)
+	}
+}
+
+// readBoilerplate returns the contents of the boilerplate file of the project
+func (r runtime) readBoilerplate() (string, error) {
+	bp, err := afero.ReadFile(r.projectFs(), filepath.Join("hack", "boilerplate.go.txt"))
+	return string(bp), err
+}
+
+// scaffoldOptions returns opts with the ones that make scaffolders write their messages to stdout
+func (r runtime) scaffoldOptions(opts ...scaffold.Option) []scaffold.Option {
+	return append(opts, scaffold.WithOutput(r.stdout()))
+}
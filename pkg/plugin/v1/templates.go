@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/seamounts/kubeapi/internal/cmdutil"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+	"github.com/seamounts/kubeapi/pkg/scaffold"
+	"github.com/spf13/pflag"
+)
+
+// templatesDir holds the --templates-dir flag of the subcommands that execute templates
+type templatesDir struct {
+	dir string
+}
+
+func (t *templatesDir) bindFlag(fs *pflag.FlagSet) {
+	fs.StringVar(&t.dir, "templates-dir", scaffold.DefaultTemplatesDir,
+		"directory, relative to the project root, where the built-in templates are overridden by <name>.tmpl files")
+}
+
+// templatesOption returns the option that makes scaffolders use the overridden templates
+func (t templatesDir) templatesOption() scaffold.Option {
+	return scaffold.WithTemplatesDir(t.dir)
+}
+
+type exportTemplatesPlugin struct {
+	config *config.Config
+
+	// outputDir is where the templates are exported to
+	outputDir string
+
+	// force indicates that the templates that were already exported should be overwritten
+	force bool
+
+	dryRun
+	runtime
+}
+
+var (
+	_ plugin.ExportTemplates = &exportTemplatesPlugin{}
+	_ cmdutil.RunOptions     = &exportTemplatesPlugin{}
+)
+
+func (p exportTemplatesPlugin) UpdateContext(ctx *plugin.Context) {
+	ctx.Description = fmt.Sprintf(`Export the built-in templates as a starting point to override them.
+
+Each template is written to <name>.tmpl in the output directory. The scaffolding commands use the
+templates found in %[1]s (or in the directory set with their --templates-dir flag) instead of the
+built-in ones. Besides the data of the built-in templates, overrides can use the following functions:
+  - title: "frigate" -> "Frigate"
+  - lower: "Frigate" -> "frigate"
+  - plural: "Frigate" -> "Frigates"
+  - camel: "frigate_spec" -> "frigateSpec"
+  - snake: "FrigateSpec" -> "frigate_spec"
+  - kebab: "FrigateSpec" -> "frigate-spec"
+  - importAlias: "ship.example.com", "v1" -> "shipexamplecomv1"
+`, scaffold.DefaultTemplatesDir)
+	ctx.Examples = fmt.Sprintf(`  # Export the templates to override them in this project
+  %s templates export
+
+  # Add a label to the types of every new API
+  nano %s/types.tmpl
+`,
+		ctx.CommandName, scaffold.DefaultTemplatesDir)
+}
+
+func (p *exportTemplatesPlugin) BindFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&p.outputDir, "output-dir", scaffold.DefaultTemplatesDir, "directory, relative to the project root, to export the templates to")
+	fs.BoolVar(&p.force, "force", false, "overwrite the templates that were already exported")
+	p.dryRun.bindFlag(fs)
+}
+
+func (p *exportTemplatesPlugin) InjectConfig(c *config.Config) {
+	p.config = c
+}
+
+func (p *exportTemplatesPlugin) Run() error {
+	return cmdutil.Run(p, p.projectRuntime())
+}
+
+func (p *exportTemplatesPlugin) Validate() error {
+	return nil
+}
+
+func (p *exportTemplatesPlugin) GetScaffolder() (scaffold.Scaffolder, error) {
+	return scaffold.NewExportTemplatesScaffolder(p.outputDir, p.force, p.scaffoldOptions()...), nil
+}
+
+func (p *exportTemplatesPlugin) PostScaffold() error {
+	if p.outputDir != scaffold.DefaultTemplatesDir {
+		fmt.Fprintf(p.stdout(), "Next: use them with the --templates-dir=%s flag\n", p.outputDir)
+	}
+	return nil
+}
@@ -3,8 +3,11 @@ package scaffold
 import (
 	"fmt"
 
+	"github.com/spf13/afero"
+
 	"github.com/seamounts/kubeapi/pkg/model"
 	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/file"
 	"github.com/seamounts/kubeapi/pkg/model/resource"
 	"github.com/seamounts/kubeapi/pkg/scaffold/internal/machinery"
 	"github.com/seamounts/kubeapi/pkg/scaffold/internal/templates"
@@ -12,40 +15,70 @@ import (
 
 type apiScaffolder struct {
 	config      *config.Config
-	resource    *resource.Resource
+	resources   []*resource.Resource
 	boilerplate string
+	fs          file.Filesystem
+	options
+}
+
+func NewAPIScaffolder(config *config.Config, boilerplate string, res *resource.Resource,
+	opts ...Option) Scaffolder {
+	return NewAPIsScaffolder(config, boilerplate, []*resource.Resource{res}, opts...)
 }
 
-func NewAPIScaffolder(config *config.Config, boilerplate string, res *resource.Resource) Scaffolder {
-	s := &apiScaffolder{
-		config:   config,
-		resource: res,
+// NewAPIsScaffolder returns a new Scaffolder that scaffolds the APIs of several resources at once
+func NewAPIsScaffolder(config *config.Config, boilerplate string, resources []*resource.Resource,
+	opts ...Option) Scaffolder {
+	return &apiScaffolder{
+		config:    config,
+		resources: resources,
+		fs:        file.Filesystem{FS: afero.NewOsFs()},
+		options:   newOptions(opts),
 	}
+}
 
-	return s
+// InjectFS implements Scaffolder
+func (s *apiScaffolder) InjectFS(fs file.Filesystem) {
+	s.fs = fs
 }
 
 // Scaffold implements Scaffolder
 func (s *apiScaffolder) Scaffold() error {
-	fmt.Println("Writing scaffold for you to edit...")
+	fmt.Fprintln(s.out, "Writing scaffold for you to edit...")
 	return s.scaffold()
 }
 
 func (s *apiScaffolder) scaffold() error {
-	machinery.NewScaffold().Execute(
+	install, err := newInstall(s.fs.FS, s.config.Repo, s.resources...)
+	if err != nil {
+		return err
+	}
+
+	// The doc and register files are scaffolded once per group-version, and the doc files list their kinds
+	var builders, docKinds []file.Builder
+	groupVersions := make(map[string]struct{}, len(s.resources))
+	for _, res := range s.resources {
+		builders = append(builders, &templates.Types{ResourceMixin: file.ResourceMixin{Resource: res}})
+		docKinds = append(docKinds, &templates.DocKind{ResourceMixin: file.ResourceMixin{Resource: res}})
+		gv := res.Group + "/" + res.Version
+		if _, found := groupVersions[gv]; found {
+			continue
+		}
+		groupVersions[gv] = struct{}{}
+		builders = append(builders,
+			&templates.Doc{ResourceMixin: file.ResourceMixin{Resource: res}},
+			&templates.Register{ResourceMixin: file.ResourceMixin{Resource: res}},
+		)
+	}
+
+	return machinery.NewScaffold(s.fs, machinery.TemplatesDir(s.templatesDir)).Execute(
 		s.newUniverse(),
-		&templates.Types{},
-		&templates.Doc{},
-		&templates.Register{},
+		append(append(builders, docKinds...), install)...,
 	)
-
-	return nil
 }
 
 func (s *apiScaffolder) newUniverse() *model.Universe {
 	return model.NewUniverse(
 		model.WithConfig(s.config),
-		model.WithResource(s.resource),
 	)
-
 }
@@ -0,0 +1,16 @@
+package scaffold
+
+import (
+	"github.com/seamounts/kubeapi/pkg/scaffold/internal/machinery"
+)
+
+// IsFileAlreadyExistsError checks if the returned error is because a scaffolded file already existed when
+// expected not to
+func IsFileAlreadyExistsError(err error) bool {
+	return machinery.IsFileAlreadyExistsError(err) || machinery.IsModelAlreadyExistsError(err)
+}
+
+// IsMergeConflictError checks if the returned error is because scaffolded files have merge conflicts
+func IsMergeConflictError(err error) bool {
+	return machinery.IsMergeConflictError(err)
+}
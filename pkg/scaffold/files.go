@@ -0,0 +1,54 @@
+package scaffold
+
+import (
+	"fmt"
+
+	"github.com/spf13/afero"
+
+	"github.com/seamounts/kubeapi/pkg/model"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/scaffold/internal/machinery"
+	"github.com/seamounts/kubeapi/pkg/scaffold/internal/templates"
+)
+
+type filesScaffolder struct {
+	config *config.Config
+	files  []file.File
+	fs     file.Filesystem
+	options
+}
+
+// NewFilesScaffolder returns a new Scaffolder that writes files whose contents were already rendered,
+// e.g. by an external plugin
+func NewFilesScaffolder(config *config.Config, files []file.File, opts ...Option) Scaffolder {
+	return &filesScaffolder{
+		config:  config,
+		files:   files,
+		fs:      file.Filesystem{FS: afero.NewOsFs()},
+		options: newOptions(opts),
+	}
+}
+
+// InjectFS implements Scaffolder
+func (s *filesScaffolder) InjectFS(fs file.Filesystem) {
+	s.fs = fs
+}
+
+// Scaffold implements Scaffolder
+func (s *filesScaffolder) Scaffold() error {
+	fmt.Fprintln(s.out, "Writing scaffold for you to edit...")
+
+	builders := make([]file.Builder, 0, len(s.files))
+	for _, f := range s.files {
+		raw := &templates.Raw{Contents: f.Contents}
+		raw.Path = f.Path
+		raw.IfExistsAction = f.IfExistsAction
+		builders = append(builders, raw)
+	}
+
+	return machinery.NewScaffold(s.fs).Execute(
+		model.NewUniverse(model.WithConfig(s.config)),
+		builders...,
+	)
+}
@@ -2,11 +2,13 @@ package scaffold
 
 import (
 	"fmt"
-	"io/ioutil"
 	"path/filepath"
 
+	"github.com/spf13/afero"
+
 	"github.com/seamounts/kubeapi/pkg/model"
 	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/file"
 	"github.com/seamounts/kubeapi/pkg/scaffold/internal/machinery"
 	"github.com/seamounts/kubeapi/pkg/scaffold/internal/templates"
 )
@@ -16,18 +18,27 @@ type initScaffolder struct {
 	boilerplatePath string
 	license         string
 	owner           string
+	fs              file.Filesystem
+	options
 }
 
 // NewInitScaffolder returns a new Scaffolder for project initialization operations
-func NewInitScaffolder(config *config.Config, license, owner string) Scaffolder {
+func NewInitScaffolder(config *config.Config, license, owner string, opts ...Option) Scaffolder {
 	return &initScaffolder{
 		config:          config,
 		boilerplatePath: filepath.Join("hack", "boilerplate.go.txt"),
 		license:         license,
 		owner:           owner,
+		fs:              file.Filesystem{FS: afero.NewOsFs()},
+		options:         newOptions(opts),
 	}
 }
 
+// InjectFS implements Scaffolder
+func (s *initScaffolder) InjectFS(fs file.Filesystem) {
+	s.fs = fs
+}
+
 func (s *initScaffolder) newUniverse(boilerplate string) *model.Universe {
 	return model.NewUniverse(
 		model.WithConfig(s.config),
@@ -37,7 +48,7 @@ func (s *initScaffolder) newUniverse(boilerplate string) *model.Universe {
 
 // Scaffold implements Scaffolder
 func (s *initScaffolder) Scaffold() error {
-	fmt.Println("Writing scaffold for you to edit...")
+	fmt.Fprintln(s.out, "Writing scaffold for you to edit...")
 
 	switch {
 	case s.config.IsV1():
@@ -53,23 +64,21 @@ func (s *initScaffolder) scaffold() error {
 	bpFile.License = s.license
 	bpFile.Owner = s.owner
 
-	if err := machinery.NewScaffold().Execute(
+	if err := machinery.NewScaffold(s.fs).Execute(
 		s.newUniverse(""),
 		bpFile,
 	); err != nil {
 		return err
 	}
 
-	boilerplate, err := ioutil.ReadFile(s.boilerplatePath) //nolint:gosec
+	boilerplate, err := afero.ReadFile(s.fs.FS, s.boilerplatePath)
 	if err != nil {
 		return err
 	}
 
-	return machinery.NewScaffold().Execute(
+	return machinery.NewScaffold(s.fs, machinery.TemplatesDir(s.templatesDir)).Execute(
 		s.newUniverse(string(boilerplate)),
 		&templates.GitIgnore{},
 		&templates.GoMod{},
 	)
-
-	return nil
 }
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/afero"
+
+	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+	"github.com/seamounts/kubeapi/pkg/scaffold/internal/templates"
+)
+
+// installDir is the directory of the package that registers every group version, which is not a group
+const installDir = "install"
+
+// newInstall returns the template of the install package of the project of fs, which registers its group
+// versions, i.e. the directories of apis/ with a register.go file, and the ones of the resources being scaffolded
+func newInstall(fs afero.Fs, repo string, resources ...*resource.Resource) (*templates.Install, error) {
+	gvs := make(map[string]templates.GroupVersion)
+	add := func(group, version string) {
+		pkg := path.Join(repo, "apis", group, version)
+		gvs[pkg] = templates.GroupVersion{Package: pkg, ImportAlias: file.ImportAlias(group, version)}
+	}
+
+	groups, err := afero.ReadDir(fs, "apis")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, group := range groups {
+		if !group.IsDir() || group.Name() == installDir {
+			continue
+		}
+		versions, err := afero.ReadDir(fs, filepath.Join("apis", group.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range versions {
+			if !version.IsDir() {
+				continue
+			}
+			registered, err := afero.Exists(fs, filepath.Join("apis", group.Name(), version.Name(), "register.go"))
+			if err != nil {
+				return nil, err
+			}
+			if registered {
+				add(group.Name(), version.Name())
+			}
+		}
+	}
+	for _, res := range resources {
+		add(res.Group, res.Version)
+	}
+
+	install := &templates.Install{GroupVersions: make([]templates.GroupVersion, 0, len(gvs))}
+	for _, gv := range gvs {
+		install.GroupVersions = append(install.GroupVersions, gv)
+	}
+	sort.Slice(install.GroupVersions, func(i, j int) bool {
+		return install.GroupVersions[i].Package < install.GroupVersions[j].Package
+	})
+	return install, nil
+}
@@ -1,7 +1,13 @@
 package scaffold
 
+import (
+	"github.com/seamounts/kubeapi/pkg/model/file"
+)
+
 // Scaffolder interface creates files to set up a controller manager
 type Scaffolder interface {
+	// InjectFS sets the file system where files are read from and written to
+	InjectFS(file.Filesystem)
 	// Scaffold performs the scaffolding
 	Scaffold() error
 }
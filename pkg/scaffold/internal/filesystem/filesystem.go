@@ -73,6 +73,13 @@ func New(options ...Options) FileSystem {
 // Options configure FileSystem
 type Options func(system *fileSystem)
 
+// Backend makes FileSystem read and write files through the provided afero.Fs
+func Backend(backend afero.Fs) Options {
+	return func(fs *fileSystem) {
+		fs.fs = backend
+	}
+}
+
 // DirectoryPermissions makes FileSystem.Create use the provided directory
 // permissions
 func DirectoryPermissions(dirPerm os.FileMode) Options {
@@ -3,6 +3,7 @@ package machinery
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/seamounts/kubeapi/pkg/model/file"
 )
@@ -52,3 +53,18 @@ func (e unknownIfExistsActionError) Error() string {
 func IsUnknownIfExistsActionError(err error) bool {
 	return errors.As(err, &unknownIfExistsActionError{})
 }
+
+// mergeConflictError is returned if the changes made to scaffolded files could not be merged with the new scaffold
+type mergeConflictError struct {
+	paths []string
+}
+
+// Error implements error interface
+func (e mergeConflictError) Error() string {
+	return fmt.Sprintf("failed to merge %s: resolve the conflicts marked in the files", strings.Join(e.paths, ", "))
+}
+
+// IsMergeConflictError checks if the returned error is because scaffolded files have merge conflicts
+func IsMergeConflictError(err error) bool {
+	return errors.As(err, &mergeConflictError{})
+}
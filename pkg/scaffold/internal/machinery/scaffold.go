@@ -3,15 +3,28 @@ package machinery
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
+	"sort"
+	"strings"
 	"text/template"
 
 	"github.com/seamounts/kubeapi/pkg/model"
 	"github.com/seamounts/kubeapi/pkg/model/file"
 	"github.com/seamounts/kubeapi/pkg/scaffold/internal/filesystem"
+	"github.com/seamounts/kubeapi/pkg/scaffold/internal/merge"
+	"github.com/spf13/afero"
 	"golang.org/x/tools/imports"
 )
 
+// BaseDir is the directory where the last scaffolded content of each file is stored, so that
+// later scaffolds can be merged with the changes made to the file since then
+const BaseDir = ".kubeapi/base"
+
+// DefaultTemplatesDir is the directory where the bodies of overridable templates are looked up,
+// as <name>.tmpl files, before falling back to the built-in ones
+const DefaultTemplatesDir = ".kubeapi/templates"
+
 var options = imports.Options{
 	Comments:   true,
 	TabIndent:  true,
@@ -19,7 +32,7 @@ var options = imports.Options{
 	FormatOnly: true,
 }
 
-// Scaffold uses templates to scaffold new files
+// Scaffold uses templates to scaffold new files and inserters to add code fragments to existing ones
 type Scaffold interface {
 	// Execute writes to disk the provided files
 	Execute(universe *model.Universe, files ...file.Builder) error
@@ -29,12 +42,44 @@ type Scaffold interface {
 type scaffold struct {
 	// fs allows to mock the file system for tests
 	fs filesystem.FileSystem
+	// record is notified of every change made to a file
+	record func(file.Change)
+	// templatesDir is where template bodies are overridden
+	templatesDir string
+}
+
+// NewScaffold returns a new Scaffold that writes to the provided file system
+func NewScaffold(fs file.Filesystem, options ...Option) Scaffold {
+	if fs.FS == nil {
+		fs.FS = afero.NewOsFs()
+	}
+	if fs.Record == nil {
+		fs.Record = func(file.Change) {}
+	}
+
+	s := &scaffold{
+		fs:           filesystem.New(filesystem.Backend(fs.FS)),
+		record:       fs.Record,
+		templatesDir: DefaultTemplatesDir,
+	}
+
+	// Apply options
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
 }
 
-// NewScaffold returns a new Scaffold with the provided plugins
-func NewScaffold() Scaffold {
-	return &scaffold{
-		fs: filesystem.New(),
+// Option configures Scaffold
+type Option func(*scaffold)
+
+// TemplatesDir makes Scaffold look up the overridden template bodies in the provided directory
+func TemplatesDir(dir string) Option {
+	return func(s *scaffold) {
+		if dir != "" {
+			s.templatesDir = dir
+		}
 	}
 }
 
@@ -48,24 +93,48 @@ func (s *scaffold) Execute(universe *model.Universe, files ...file.Builder) erro
 		imports.LocalPrefix = universe.Config.Repo
 	}
 
+	// Paths of the files that were read from disk to insert code fragments into them
+	loaded := make(map[string]struct{})
+
 	for _, f := range files {
 		// Inject common fields
 		universe.InjectInto(f)
 
-		// Build models for Template builders
 		if t, isTemplate := f.(file.Template); isTemplate {
+			// Build models for Template builders
 			if err := s.buildFileModel(t, universe.Files); err != nil {
 				return err
 			}
+		} else if i, isInserter := f.(file.Inserter); isInserter {
+			// Update models for Inserter builders
+			if err := s.updateFileModel(i, universe.Files, loaded); err != nil {
+				return err
+			}
 		}
 	}
 
 	// Persist the files to disk
+	var conflicts []string
 	for _, f := range universe.Files {
+		// Files that were only updated with code fragments already contain the changes made to them
+		if _, found := loaded[f.Path]; found {
+			if err := s.update(f.Path, f.Contents); err != nil {
+				return err
+			}
+			continue
+		}
+
 		if err := s.writeFile(f); err != nil {
-			return err
+			if !IsMergeConflictError(err) {
+				return err
+			}
+			conflicts = append(conflicts, f.Path)
 		}
 	}
+	if len(conflicts) != 0 {
+		sort.Strings(conflicts)
+		return mergeConflictError{conflicts}
+	}
 
 	return nil
 }
@@ -95,21 +164,166 @@ func (s *scaffold) buildFileModel(t file.Template, models map[string]*file.File)
 		IfExistsAction: t.GetIfExistsAction(),
 	}
 
-	b, err := doTemplate(t)
+	body, err := s.templateBody(t)
 	if err != nil {
 		return err
 	}
+
+	b, err := doTemplate(t, body)
+	if err != nil {
+		return fmt.Errorf("unable to scaffold %s: %w", t.GetPath(), err)
+	}
+	m.Contents = string(b)
+
+	models[m.Path] = m
+
+	return nil
+
+}
+
+func (s *scaffold) updateFileModel(i file.Inserter, models map[string]*file.File, loaded map[string]struct{}) error {
+	// Set the inserter default values
+	if err := i.SetTemplateDefaults(); err != nil {
+		return err
+	}
+
+	m, found := models[i.GetPath()]
+	if found && m.IfExistsAction == file.Skip {
+		// Existing files are kept instead of the models of skipped templates, so the code fragments are inserted
+		// into them
+		exists, err := s.fs.Exists(i.GetPath())
+		if err != nil {
+			return err
+		}
+		found = !exists
+	}
+	if !found {
+		// Code fragments can only be inserted into existing files
+		exists, err := s.fs.Exists(i.GetPath())
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return nil
+		}
+
+		content, err := s.read(i.GetPath())
+		if err != nil {
+			return err
+		}
+
+		m = &file.File{
+			Path:           i.GetPath(),
+			Contents:       content,
+			IfExistsAction: i.GetIfExistsAction(),
+		}
+		loaded[m.Path] = struct{}{}
+	}
+
+	b := []byte(insertStrings(m.Contents, i.GetMarkers(), i.GetCodeFragments()))
+
+	// gofmt the imports
+	if filepath.Ext(m.Path) == ".go" {
+		var err error
+		b, err = imports.Process(m.Path, b, &options)
+		if err != nil {
+			return err
+		}
+	}
 	m.Contents = string(b)
 
 	models[m.Path] = m
 
 	return nil
+}
+
+// insertStrings inserts the code fragments right before the line of their marker, with the
+// same indentation, skipping those that are already present so that insertions are idempotent
+func insertStrings(content string, markers []file.Marker, codeFragmentsMap file.CodeFragmentsMap) string {
+	out := &strings.Builder{}
+	for _, line := range strings.SplitAfter(content, "\n") {
+		for _, marker := range markers {
+			if strings.TrimSpace(line) != marker.String() {
+				continue
+			}
+
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			for _, fragment := range codeFragmentsMap[marker] {
+				if containsFragment(content, fragment) || containsFragment(out.String(), fragment) {
+					continue
+				}
+				for _, fragmentLine := range strings.Split(strings.TrimSuffix(fragment, "\n"), "\n") {
+					if fragmentLine != "" {
+						out.WriteString(indent)
+					}
+					out.WriteString(fragmentLine + "\n")
+				}
+			}
+		}
+		out.WriteString(line)
+	}
+
+	return out.String()
+}
 
+// containsFragment returns true if the non-empty lines of the code fragment are already present
+// as consecutive lines of content, ignoring indentation
+func containsFragment(content, fragment string) bool {
+	fragmentLines := trimmedLines(fragment)
+	if len(fragmentLines) == 0 {
+		return true
+	}
+
+	contentLines := trimmedLines(content)
+	for i := 0; i+len(fragmentLines) <= len(contentLines); i++ {
+		found := true
+		for j, fragmentLine := range fragmentLines {
+			if contentLines[i+j] != fragmentLine {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+
+	return false
+}
+
+// trimmedLines returns the non-empty lines of s without surrounding whitespace
+func trimmedLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
 }
 
-// doTemplate executes the template for a file using the input
-func doTemplate(t file.Template) ([]byte, error) {
-	temp, err := newTemplate(t).Parse(t.GetBody())
+// templateBody returns the body of a template, which may be overridden in the templates directory
+func (s *scaffold) templateBody(t file.Template) (string, error) {
+	o, isOverridable := t.(file.Overridable)
+	if !isOverridable {
+		return t.GetBody(), nil
+	}
+
+	path := filepath.Join(s.templatesDir, o.GetTemplateName()+".tmpl")
+	exists, err := s.fs.Exists(path)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return t.GetBody(), nil
+	}
+
+	return s.read(path)
+}
+
+// doTemplate executes the template body for a file using the input
+func doTemplate(t file.Template, body string) ([]byte, error) {
+	temp, err := newTemplate(t).Parse(body)
 	if err != nil {
 		return nil, err
 	}
@@ -149,25 +363,122 @@ func (s *scaffold) writeFile(f *file.File) error {
 	if err != nil {
 		return err
 	}
-	if exists {
-		switch f.IfExistsAction {
-		case file.Overwrite:
-			// By not returning, the file is written as if it didn't exist
-		case file.Skip:
-			// By returning nil, the file is not written but the process will carry on
-			return nil
-		case file.Error:
-			// By returning an error, the file is not written and the process will fail
-			return fileAlreadyExistsError{f.Path}
+	if !exists {
+		if err := s.write(f.Path, f.Contents); err != nil {
+			return err
+		}
+		s.record(file.Change{Path: f.Path, Action: file.Created, Contents: f.Contents})
+
+		// Remember the scaffolded content as the base for the next merge
+		return s.write(basePath(f.Path), f.Contents)
+	}
+
+	switch f.IfExistsAction {
+	case file.Overwrite:
+		// Instead of blindly overwriting, keep the changes made to the file since it was last scaffolded
+		contents, conflict, err := s.merge(f)
+		if err != nil {
+			return err
+		}
+		if err := s.update(f.Path, contents); err != nil {
+			return err
+		}
+
+		// Remember the scaffolded content as the base for the next merge
+		if err := s.write(basePath(f.Path), f.Contents); err != nil {
+			return err
 		}
+
+		if conflict {
+			return mergeConflictError{[]string{f.Path}}
+		}
+		return nil
+	case file.Skip:
+		// By returning nil, the file is not written but the process will carry on
+		s.record(file.Change{Path: f.Path, Action: file.Skipped, Reason: "the file already exists"})
+		return nil
+	case file.Error:
+		// By returning an error, the file is not written and the process will fail
+		return fileAlreadyExistsError{f.Path}
+	default:
+		return unknownIfExistsActionError{f.Path, f.IfExistsAction}
 	}
+}
 
-	writer, err := s.fs.Create(f.Path)
+// update writes the new contents of an existing file
+func (s *scaffold) update(path, contents string) error {
+	previous, err := s.read(path)
 	if err != nil {
 		return err
 	}
 
-	_, err = writer.Write([]byte(f.Contents))
+	if previous == contents {
+		s.record(file.Change{Path: path, Action: file.Unchanged, Reason: "the file already has the scaffolded content",
+			Previous: previous, Contents: contents})
+		return nil
+	}
+
+	if err := s.write(path, contents); err != nil {
+		return err
+	}
+	s.record(file.Change{Path: path, Action: file.Updated, Previous: previous, Contents: contents})
+
+	return nil
+}
+
+// merge returns the three-way merge of the last scaffolded content, the current content and the new
+// scaffolded content of a file. When the file was never scaffolded before, any difference between the
+// current and the new content is a conflict.
+func (s *scaffold) merge(f *file.File) (string, bool, error) {
+	current, err := s.read(f.Path)
+	if err != nil {
+		return "", false, err
+	}
+
+	base := current
+	hasBase, err := s.fs.Exists(basePath(f.Path))
+	if err != nil {
+		return "", false, err
+	}
+	if hasBase {
+		if base, err = s.read(basePath(f.Path)); err != nil {
+			return "", false, err
+		}
+	} else if current != f.Contents {
+		base = ""
+	}
+
+	merged, conflict := merge.Merge(base, current, f.Contents)
+	return merged, conflict, nil
+}
+
+func (s *scaffold) read(path string) (string, error) {
+	reader, err := s.fs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+func (s *scaffold) write(path, contents string) error {
+	writer, err := s.fs.Create(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write([]byte(contents))
 
 	return err
 }
+
+// basePath returns the path where the last scaffolded content of a file is stored
+func basePath(path string) string {
+	return filepath.Join(BaseDir, path)
+}
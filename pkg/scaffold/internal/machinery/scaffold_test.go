@@ -0,0 +1,143 @@
+package machinery
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/seamounts/kubeapi/pkg/model"
+	"github.com/seamounts/kubeapi/pkg/model/file"
+)
+
+const kindsPath = "apis/ship/kinds.go"
+
+// kindsFile is a file whose kinds are listed by kindsInserter
+const kindsFile = `package ship
+
+// Kinds are the kinds of the group
+var Kinds = []string{
+	"Frigate",
+	// +kubeapi:scaffold:kinds
+}
+`
+
+// kindsTemplate scaffolds kindsFile, unless it already exists
+type kindsTemplate struct {
+	file.TemplateMixin
+}
+
+func (f *kindsTemplate) SetTemplateDefaults() error {
+	f.Path = kindsPath
+	f.TemplateBody = kindsFile
+	f.IfExistsAction = file.Skip
+	return nil
+}
+
+// kindsInserter lists kinds in the file at path
+type kindsInserter struct {
+	file.InserterMixin
+
+	kinds  []string
+	marker file.Marker
+}
+
+func (f *kindsInserter) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = kindsPath
+	}
+
+	var err error
+	f.marker, err = file.NewMarkerFor(f.Path, "kinds")
+	return err
+}
+
+func (f *kindsInserter) GetMarkers() []file.Marker {
+	return []file.Marker{f.marker}
+}
+
+func (f *kindsInserter) GetCodeFragments() file.CodeFragmentsMap {
+	fragments := make(file.CodeFragments, 0, len(f.kinds))
+	for _, kind := range f.kinds {
+		fragments = append(fragments, `"`+kind+`",`+"\n")
+	}
+	return file.CodeFragmentsMap{f.marker: fragments}
+}
+
+func TestInserter(t *testing.T) {
+	for name, test := range map[string]struct {
+		// existing is the content of the file before scaffolding, if it exists
+		existing string
+		builders []file.Builder
+		expected string
+		actions  []file.Action
+	}{
+		"existing file": {
+			existing: kindsFile,
+			builders: []file.Builder{&kindsInserter{kinds: []string{"Sloop", "Frigate", "Corvette"}}},
+			expected: strings.Replace(kindsFile, "\t// +kubeapi", "\t\"Sloop\",\n\t\"Corvette\",\n\t// +kubeapi", 1),
+			actions:  []file.Action{file.Updated, file.Unchanged},
+		},
+		"scaffolded file": {
+			builders: []file.Builder{&kindsTemplate{}, &kindsInserter{kinds: []string{"Sloop"}}},
+			expected: strings.Replace(kindsFile, "\t// +kubeapi", "\t\"Sloop\",\n\t// +kubeapi", 1),
+			actions:  []file.Action{file.Created, file.Unchanged},
+		},
+		"skipped template": {
+			existing: strings.Replace(kindsFile, "Frigate", "Corvette", 1),
+			builders: []file.Builder{&kindsTemplate{}, &kindsInserter{kinds: []string{"Sloop"}}},
+			expected: strings.Replace(kindsFile, "\t\"Frigate\",\n", "\t\"Corvette\",\n\t\"Sloop\",\n", 1),
+			actions:  []file.Action{file.Updated, file.Unchanged},
+		},
+		"missing file": {
+			builders: []file.Builder{&kindsInserter{kinds: []string{"Sloop"}}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if test.existing != "" {
+				if err := afero.WriteFile(fs, kindsPath, []byte(test.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// The code fragments are inserted once, even if the files are scaffolded twice
+			var actions []file.Action
+			record := func(change file.Change) {
+				if change.Path == kindsPath {
+					actions = append(actions, change.Action)
+				}
+			}
+			for i := 0; i < 2; i++ {
+				s := NewScaffold(file.Filesystem{FS: fs, Record: record})
+				if err := s.Execute(model.NewUniverse(), test.builders...); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			contents, err := afero.ReadFile(fs, kindsPath)
+			switch {
+			case test.expected == "" && err == nil:
+				t.Errorf("%s was created:\n%s", kindsPath, contents)
+			case test.expected != "" && err != nil:
+				t.Fatal(err)
+			case string(contents) != test.expected:
+				t.Errorf("%s = %q, want %q", kindsPath, contents, test.expected)
+			}
+			if !reflect.DeepEqual(actions, test.actions) {
+				t.Errorf("the changes of %s are %v, want %v", kindsPath, actions, test.actions)
+			}
+		})
+	}
+}
+
+func TestInserterUnknownExtension(t *testing.T) {
+	s := NewScaffold(file.Filesystem{FS: afero.NewMemMapFs()})
+	err := s.Execute(model.NewUniverse(), &kindsInserter{InserterMixin: file.InserterMixin{
+		PathMixin: file.PathMixin{Path: "kinds.txt"},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "unknown file extension") {
+		t.Errorf("expected an error for the unknown extension, got %v", err)
+	}
+}
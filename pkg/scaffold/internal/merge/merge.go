@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package merge implements a line based three-way merge of scaffolded files.
+package merge
+
+import (
+	"strings"
+
+	"github.com/seamounts/kubeapi/internal/diff"
+)
+
+const (
+	currentMarker    = "<<<<<<< current\n"
+	separatorMarker  = "=======\n"
+	scaffoldedMarker = ">>>>>>> scaffolded\n"
+)
+
+// Merge combines the changes made to base in current (the file as edited by the user) and in
+// scaffolded (the new template output). Regions changed in both that cannot be reconciled are
+// surrounded by conflict markers, in which case the returned bool is true.
+func Merge(base, current, scaffolded string) (string, bool) {
+	baseLines, currentLines, scaffoldedLines := diff.SplitLines(base), diff.SplitLines(current), diff.SplitLines(scaffolded)
+	toCurrent := diff.Match(baseLines, currentLines)
+	toScaffolded := diff.Match(baseLines, scaffoldedLines)
+
+	var out strings.Builder
+	conflict := false
+
+	i, c, s := 0, 0, 0
+	for i < len(baseLines) || c < len(currentLines) || s < len(scaffoldedLines) {
+		// Stable line: unchanged in both versions
+		if i < len(baseLines) && toCurrent[i] == c && toScaffolded[i] == s {
+			out.WriteString(baseLines[i])
+			i, c, s = i+1, c+1, s+1
+			continue
+		}
+
+		// Find the end of the unstable chunk: the next base line kept by both versions
+		k, cEnd, sEnd := i, len(currentLines), len(scaffoldedLines)
+		for ; k < len(baseLines); k++ {
+			if toCurrent[k] != -1 && toScaffolded[k] != -1 {
+				cEnd, sEnd = toCurrent[k], toScaffolded[k]
+				break
+			}
+		}
+
+		baseChunk, currentChunk, scaffoldedChunk := baseLines[i:k], currentLines[c:cEnd], scaffoldedLines[s:sEnd]
+		switch {
+		case equal(currentChunk, baseChunk):
+			writeLines(&out, scaffoldedChunk)
+		case equal(scaffoldedChunk, baseChunk), equal(currentChunk, scaffoldedChunk):
+			writeLines(&out, currentChunk)
+		default:
+			conflict = true
+			out.WriteString(currentMarker)
+			writeTerminatedLines(&out, currentChunk)
+			out.WriteString(separatorMarker)
+			writeTerminatedLines(&out, scaffoldedChunk)
+			out.WriteString(scaffoldedMarker)
+		}
+
+		i, c, s = k, cEnd, sEnd
+	}
+
+	return out.String(), conflict
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeLines(out *strings.Builder, lines []string) {
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+}
+
+// writeTerminatedLines writes lines making sure the last one ends with a line terminator,
+// so that conflict markers always start on their own line
+func writeTerminatedLines(out *strings.Builder, lines []string) {
+	writeLines(out, lines)
+	if len(lines) != 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		out.WriteString("\n")
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	for name, test := range map[string]struct {
+		base, current, scaffolded string
+		merged                    string
+		conflict                  bool
+	}{
+		"unchanged": {
+			base:       "a\nb\nc\n",
+			current:    "a\nb\nc\n",
+			scaffolded: "a\nb\nc\n",
+			merged:     "a\nb\nc\n",
+		},
+		"scaffolded change only": {
+			base:       "a\nb\nc\n",
+			current:    "a\nb\nc\n",
+			scaffolded: "a\nB\nc\n",
+			merged:     "a\nB\nc\n",
+		},
+		"current insertion only": {
+			base:       "a\nb\nc\n",
+			current:    "a\nx\nb\nc\n",
+			scaffolded: "a\nb\nc\n",
+			merged:     "a\nx\nb\nc\n",
+		},
+		"current deletion only": {
+			base:       "a\nb\nc\n",
+			current:    "a\nc\n",
+			scaffolded: "a\nb\nc\n",
+			merged:     "a\nc\n",
+		},
+		"clean merge of both sides": {
+			base:       "a\nb\nc\nd\ne\n",
+			current:    "a\nB\nc\nd\ne\n",
+			scaffolded: "a\nb\nc\nd\nE\n",
+			merged:     "a\nB\nc\nd\nE\n",
+		},
+		"same change on both sides": {
+			base:       "a\nb\nc\n",
+			current:    "a\nX\nc\n",
+			scaffolded: "a\nX\nc\n",
+			merged:     "a\nX\nc\n",
+		},
+		"conflicting hunks": {
+			base:       "a\nb\nc\n",
+			current:    "a\nB1\nc\n",
+			scaffolded: "a\nB2\nc\n",
+			merged:     "a\n<<<<<<< current\nB1\n=======\nB2\n>>>>>>> scaffolded\nc\n",
+			conflict:   true,
+		},
+		"conflicting last lines without line terminator": {
+			base:       "a\nb",
+			current:    "a\nx",
+			scaffolded: "a\ny",
+			merged:     "a\n<<<<<<< current\nx\n=======\ny\n>>>>>>> scaffolded\n",
+			conflict:   true,
+		},
+		"empty base with identical files": {
+			current:    "a\nb\n",
+			scaffolded: "a\nb\n",
+			merged:     "a\nb\n",
+		},
+		"empty base with different files": {
+			current:    "a\n",
+			scaffolded: "b\n",
+			merged:     "<<<<<<< current\na\n=======\nb\n>>>>>>> scaffolded\n",
+			conflict:   true,
+		},
+		"empty base with an empty current file": {
+			scaffolded: "a\n",
+			merged:     "a\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			merged, conflict := Merge(test.base, test.current, test.scaffolded)
+			if merged != test.merged || conflict != test.conflict {
+				t.Errorf("Merge() = %q, %t, want %q, %t", merged, conflict, test.merged, test.conflict)
+			}
+		})
+	}
+}
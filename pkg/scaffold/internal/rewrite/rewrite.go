@@ -0,0 +1,383 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rewrite edits existing go source files through their syntax tree so that
+// declarations and their references can be renamed or moved between packages.
+package rewrite
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/afero"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+const generatedPrefix = "zz_generated"
+
+// skippedDirs are never walked when looking for references
+var skippedDirs = map[string]struct{}{
+	"vendor":   {},
+	"testdata": {},
+}
+
+// PrefixedDecls returns the top-level declarations of the package in dir whose name is prefix
+// or starts with prefix followed by an uppercase letter (e.g. Frigate, FrigateSpec, FrigateList),
+// except those that are prefixed in the same way by any of the longer excluded prefixes
+// (e.g. FrigateClass and FrigateClassList when FrigateClass is excluded).
+func PrefixedDecls(fs afero.Fs, dir, prefix string, excluded ...string) ([]string, error) {
+	files, err := parsePackage(fs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range files {
+		for _, name := range topLevelNames(f.ast) {
+			if !hasPrefix(name, prefix) {
+				continue
+			}
+			found := false
+			for _, e := range excluded {
+				if len(e) > len(prefix) && hasPrefix(name, e) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// hasPrefix returns true if name is prefix or starts with prefix followed by an uppercase letter
+func hasPrefix(name, prefix string) bool {
+	if name == prefix {
+		return true
+	}
+	rest := strings.TrimPrefix(name, prefix)
+	return rest != name && unicode.IsUpper([]rune(rest)[0])
+}
+
+// FileDecls returns the top-level declarations of the go file at path.
+func FileDecls(fs afero.Fs, path string) ([]string, error) {
+	f, err := parseFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+
+	names := topLevelNames(f.ast)
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// PackageDecls returns the top-level declarations of the package in dir.
+func PackageDecls(fs afero.Fs, dir string) ([]string, error) {
+	files, err := parsePackage(fs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range files {
+		names = append(names, topLevelNames(f.ast)...)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// RenameIdents renames the identifiers in renames in every go file of the package in dir,
+// including the words in comments that match them.
+func RenameIdents(fs afero.Fs, dir string, renames map[string]string) error {
+	files, err := parsePackage(fs, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		ast.Inspect(f.ast, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.SelectorExpr:
+				// Only the left-hand side may refer to a package-level identifier
+				ast.Inspect(node.X, renameIdent(renames))
+				return false
+			case *ast.Ident:
+				renameIdent(renames)(node)
+			}
+			return true
+		})
+		renameInComments(f.ast, renames)
+
+		if err := f.write(fs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MoveFile moves the go file at src to dst, changing its package clause to pkgName.
+func MoveFile(fs afero.Fs, src, dst, pkgName string) error {
+	f, err := parseFile(fs, src)
+	if err != nil {
+		return err
+	}
+
+	f.ast.Name.Name = pkgName
+	f.path = dst
+	if err := f.write(fs); err != nil {
+		return err
+	}
+
+	return fs.Remove(src)
+}
+
+// RewriteReferences rewrites every go file under root that imports oldPkg so that the qualified
+// identifiers in renames point to newPkg with their new names. When newPkg is not yet imported
+// it is added with newAlias, and oldPkg is dropped once it is no longer used.
+func RewriteReferences(fs afero.Fs, root, oldPkg, newPkg, newAlias string, renames map[string]string) error {
+	return afero.Walk(fs, root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if _, skip := skippedDirs[info.Name()]; skip ||
+				(p != root && strings.HasPrefix(info.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isSource(info.Name()) {
+			return nil
+		}
+
+		f, err := parseFile(fs, p)
+		if err != nil {
+			return err
+		}
+		if changed := rewriteFile(f, oldPkg, newPkg, newAlias, renames); !changed {
+			return nil
+		}
+		return f.write(fs)
+	})
+}
+
+func rewriteFile(f *sourceFile, oldPkg, newPkg, newAlias string, renames map[string]string) bool {
+	oldName, imported := importName(f.ast, oldPkg)
+	if !imported {
+		return false
+	}
+
+	newName := oldName
+	if newPkg != oldPkg {
+		if name, found := importName(f.ast, newPkg); found {
+			newName = name
+		} else {
+			newName = newAlias
+		}
+	}
+
+	changed := false
+	ast.Inspect(f.ast, func(n ast.Node) bool {
+		sel, isSelector := n.(*ast.SelectorExpr)
+		if !isSelector {
+			return true
+		}
+		x, isIdent := sel.X.(*ast.Ident)
+		if !isIdent || x.Name != oldName || x.Obj != nil {
+			return true
+		}
+		to, found := renames[sel.Sel.Name]
+		if !found {
+			return true
+		}
+		x.Name = newName
+		sel.Sel.Name = to
+		changed = true
+		return true
+	})
+	if !changed {
+		return false
+	}
+	renameInComments(f.ast, renames)
+
+	if newPkg != oldPkg {
+		if _, found := importName(f.ast, newPkg); !found {
+			astutil.AddNamedImport(f.fset, f.ast, newAlias, newPkg)
+		}
+		if !astutil.UsesImport(f.ast, oldPkg) {
+			astutil.DeleteNamedImport(f.fset, f.ast, importAlias(f.ast, oldPkg), oldPkg)
+		}
+	}
+
+	return true
+}
+
+// importName returns the name a file uses to refer to the imported package at pkgPath
+func importName(f *ast.File, pkgPath string) (string, bool) {
+	for _, imp := range f.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p != pkgPath {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name, true
+		}
+		return path.Base(pkgPath), true
+	}
+	return "", false
+}
+
+// importAlias returns the explicit name given to the imported package at pkgPath, if any
+func importAlias(f *ast.File, pkgPath string) string {
+	for _, imp := range f.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p == pkgPath && imp.Name != nil {
+			return imp.Name.Name
+		}
+	}
+	return ""
+}
+
+func renameIdent(renames map[string]string) func(ast.Node) bool {
+	return func(n ast.Node) bool {
+		if ident, isIdent := n.(*ast.Ident); isIdent {
+			if to, found := renames[ident.Name]; found {
+				ident.Name = to
+			}
+		}
+		return true
+	}
+}
+
+func renameInComments(f *ast.File, renames map[string]string) {
+	if len(renames) == 0 {
+		return
+	}
+
+	olds := make([]string, 0, len(renames))
+	for old := range renames {
+		olds = append(olds, regexp.QuoteMeta(old))
+	}
+	// Longest names first so that FrigateList is not matched as Frigate
+	sort.Slice(olds, func(i, j int) bool { return len(olds[i]) > len(olds[j]) })
+	re := regexp.MustCompile(`\b(` + strings.Join(olds, "|") + `)\b`)
+
+	for _, group := range f.Comments {
+		for _, c := range group.List {
+			c.Text = re.ReplaceAllStringFunc(c.Text, func(word string) string {
+				return renames[word]
+			})
+		}
+	}
+}
+
+func topLevelNames(f *ast.File) []string {
+	var names []string
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil && d.Name.Name != "init" {
+				names = append(names, d.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, s.Name.Name)
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.Name != "_" {
+							names = append(names, name.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// isSource returns true for hand-written go files
+func isSource(name string) bool {
+	return filepath.Ext(name) == ".go" && !strings.HasPrefix(name, generatedPrefix)
+}
+
+type sourceFile struct {
+	path string
+	fset *token.FileSet
+	ast  *ast.File
+}
+
+func parseFile(fs afero.Fs, path string) (*sourceFile, error) {
+	src, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sourceFile{path: path, fset: fset, ast: f}, nil
+}
+
+func parsePackage(fs afero.Fs, dir string) ([]*sourceFile, error) {
+	infos, err := afero.ReadDir(fs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*sourceFile
+	for _, info := range infos {
+		if info.IsDir() || !isSource(info.Name()) || strings.HasSuffix(info.Name(), "_test.go") {
+			continue
+		}
+		f, err := parseFile(fs, filepath.Join(dir, info.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+func (f *sourceFile) write(fs afero.Fs) error {
+	var out bytes.Buffer
+	if err := format.Node(&out, f.fset, f.ast); err != nil {
+		return err
+	}
+
+	return afero.WriteFile(fs, f.path, out.Bytes(), 0600)
+}
@@ -0,0 +1,13 @@
+package templates
+
+// Defaults returns the built-in bodies of the overridable templates, by template name
+func Defaults() map[string]string {
+	return map[string]string{
+		(&Types{}).GetTemplateName():     typesTemplate,
+		(&Register{}).GetTemplateName():  registerTemplate,
+		(&Doc{}).GetTemplateName():       docTemplate,
+		(&Install{}).GetTemplateName():   installTemplate,
+		(&GoMod{}).GetTemplateName():     goModTemplae,
+		(&GitIgnore{}).GetTemplateName(): gitignoreTemplate,
+	}
+}
@@ -1,6 +1,7 @@
 package templates
 
 import (
+	"fmt"
 	"path/filepath"
 
 	"github.com/seamounts/kubeapi/pkg/model/file"
@@ -17,25 +18,72 @@ func (d *Doc) GetBody() string {
 	return d.TemplateBody
 }
 
+// GetTemplateName implements file.Overridable
+func (d *Doc) GetTemplateName() string {
+	return "doc"
+}
+
 func (d *Doc) SetTemplateDefaults() error {
 	d.Path = filepath.Join("apis", "%[group]", "%[version]", "doc.go")
 	d.Path = d.Resource.Replacer().Replace(d.Path)
 
 	d.TemplateBody = docTemplate
 
-	d.IfExistsAction = file.Error
+	// The file is shared by the kinds of the group version
+	d.IfExistsAction = file.Skip
 
 	return nil
 }
 
 const docTemplate = `
-// Package v1 contains API Schema definitions for the ecs v1 API group
+// Package {{ .Resource.Version }} contains API Schema definitions for the {{ .Resource.Group }} {{ .Resource.Version }} API group
+// ` + file.MarkerPrefix + kindsMarker + `
 // +k8s:deepcopy-gen=package,register
-// +groupName={{ .Resource.Group }}
-package v1
+// +groupName={{ .Resource.Domain }}
+package {{ .Resource.Version }}
 
 const (
-	GroupName = {{ .Resource.Group }}
-	Version = {{ .Resource.Version }}
+	// GroupName is the name of the API group
+	GroupName = "{{ .Resource.Domain }}"
+	// Version is the version of the API group
+	Version = "{{ .Resource.Version }}"
 )
 `
+
+// kindsMarker is the marker of the doc file before which the kinds of the group version are listed
+const kindsMarker = "kinds"
+
+// DocKind lists the kind of the resource in the doc file of its group version, as a +kubeapi:kind tag
+type DocKind struct {
+	file.InserterMixin
+	file.ResourceMixin
+
+	marker file.Marker
+}
+
+// SetTemplateDefaults implements file.Inserter
+func (f *DocKind) SetTemplateDefaults() error {
+	f.Path = filepath.Join("apis", "%[group]", "%[version]", "doc.go")
+	f.Path = f.Resource.Replacer().Replace(f.Path)
+
+	var err error
+	f.marker, err = file.NewMarkerFor(f.Path, kindsMarker)
+	return err
+}
+
+// GetMarkers implements file.Inserter
+func (f *DocKind) GetMarkers() []file.Marker {
+	return []file.Marker{f.marker}
+}
+
+// GetCodeFragments implements file.Inserter
+func (f *DocKind) GetCodeFragments() file.CodeFragmentsMap {
+	return file.CodeFragmentsMap{
+		f.marker: file.CodeFragments{KindTag(f.Resource.Kind)},
+	}
+}
+
+// KindTag returns the line of the doc file of a group version that lists kind
+func KindTag(kind string) string {
+	return fmt.Sprintf("// +kubeapi:kind=%s\n", kind)
+}
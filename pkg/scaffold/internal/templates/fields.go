@@ -0,0 +1,124 @@
+package templates
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gobuffalo/flect"
+
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+)
+
+// TypeStruct is a struct type of a types file
+type TypeStruct struct {
+	// Name is the name of the type
+	Name string
+	// Doc is the doc comment of the type
+	Doc string
+	// Fields are the fields of the type
+	Fields []TypeField
+}
+
+// TypeField is a field of a struct type of a types file
+type TypeField struct {
+	// Name is the name of the field
+	Name string
+	// Type is the Go type of the field
+	Type string
+	// Tag is the json tag of the field, e.g. "readyReplicas,omitempty"
+	Tag string
+	// Doc are the lines of the doc comment of the field
+	Doc []string
+	// Markers are the markers of the field, without their "+" prefix
+	Markers []string
+}
+
+// typeFields converts the resource fields of the struct type named parent to the fields of a types file,
+// appending the struct types of their nested fields to structs
+func typeFields(parent string, fields []resource.Field, structs *[]TypeStruct) []TypeField {
+	result := make([]TypeField, 0, len(fields))
+	for _, f := range fields {
+		objectName := f.ObjectName(parent)
+		if len(f.Fields) != 0 {
+			*structs = append(*structs, TypeStruct{
+				Name: objectName,
+				Doc:  fmt.Sprintf("%s is the type of the %s field of %s", objectName, f.Name, parent),
+			})
+			// The nested struct is declared before the structs of its own nested fields
+			i := len(*structs) - 1
+			nested := typeFields(objectName, f.Fields, structs)
+			(*structs)[i].Fields = nested
+		}
+
+		doc := f.Doc
+		if doc == "" {
+			doc = fmt.Sprintf("%s is the %s of the %s", f.GoName(), strings.ToLower(flect.Humanize(f.Name)), parent)
+		}
+
+		var markers []string
+		tag := f.Name + ",omitempty"
+		switch {
+		case f.Required:
+			markers = append(markers, "kubebuilder:validation:Required")
+			tag = f.Name
+		case f.Optional:
+			markers = append(markers, "optional")
+		}
+
+		result = append(result, TypeField{
+			Name:    f.GoName(),
+			Type:    f.GoType(objectName),
+			Tag:     tag,
+			Doc:     strings.Split(strings.TrimSpace(doc), "\n"),
+			Markers: append(markers, f.Markers...),
+		})
+	}
+	return result
+}
+
+// typeImports returns the import lines of the packages the types of the fields reference, except the imported
+// ones, sorted
+func typeImports(imported []string, fields ...[]resource.Field) []string {
+	packages := make(map[string]struct{})
+	for _, fs := range fields {
+		for _, f := range fs {
+			for _, pkg := range f.Packages() {
+				packages[pkg] = struct{}{}
+			}
+		}
+	}
+
+	for _, pkg := range imported {
+		delete(packages, pkg)
+	}
+
+	imports := make([]string, 0, len(packages))
+	for pkg := range packages {
+		path := resource.TypePackages[pkg]
+		if pkg == filepath.Base(path) {
+			imports = append(imports, strconv.Quote(path))
+		} else {
+			imports = append(imports, fmt.Sprintf("%s %q", pkg, path))
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// checkStructs returns an error if several struct types have the same name
+func checkStructs(structs []TypeStruct, reserved ...string) error {
+	names := make(map[string]struct{}, len(structs)+len(reserved))
+	for _, name := range reserved {
+		names[name] = struct{}{}
+	}
+	for _, s := range structs {
+		if _, found := names[s.Name]; found {
+			return fmt.Errorf("the nested struct %s of the fields is declared twice, rename one of its fields", s.Name)
+		}
+		names[s.Name] = struct{}{}
+	}
+	return nil
+}
@@ -20,13 +20,18 @@ import (
 	"github.com/seamounts/kubeapi/pkg/model/file"
 )
 
-var _ file.Template = &GitIgnore{}
+var _ file.Overridable = &GitIgnore{}
 
 // GitIgnore scaffolds the .gitignore file
 type GitIgnore struct {
 	file.TemplateMixin
 }
 
+// GetTemplateName implements file.Overridable
+func (f *GitIgnore) GetTemplateName() string {
+	return "gitignore"
+}
+
 // SetTemplateDefaults implements input.Template
 func (f *GitIgnore) SetTemplateDefaults() error {
 	if f.Path == "" {
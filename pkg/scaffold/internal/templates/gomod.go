@@ -2,11 +2,22 @@ package templates
 
 import (
 	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/model/kubernetes"
 )
 
+// defaultGoVersion is the go version of the projects that target no Kubernetes version
+const defaultGoVersion = "1.13"
+
 type GoMod struct {
 	file.TemplateMixin
 	file.RepositoryMixin
+	file.KubernetesVersionMixin
+	file.RegistrationMixin
+
+	// GoVersion is the go version of the module
+	GoVersion string
+	// Modules are the modules the project requires, none if it targets no Kubernetes version
+	Modules []kubernetes.Module
 }
 
 // GetBody implements Template
@@ -14,6 +25,11 @@ func (f *GoMod) GetBody() string {
 	return f.TemplateBody
 }
 
+// GetTemplateName implements file.Overridable
+func (f *GoMod) GetTemplateName() string {
+	return "gomod"
+}
+
 func (f *GoMod) SetTemplateDefaults() error {
 	if f.Path == "" {
 		f.Path = "go.mod"
@@ -22,11 +38,29 @@ func (f *GoMod) SetTemplateDefaults() error {
 	f.TemplateBody = goModTemplae
 	f.IfExistsAction = file.Skip
 
+	f.GoVersion = defaultGoVersion
+	if f.KubernetesVersion != "" {
+		release, err := kubernetes.Lookup(f.KubernetesVersion)
+		if err != nil {
+			return err
+		}
+		f.GoVersion = release.Go
+		f.Modules = release.Modules(!f.APIMachineryRegistration())
+	}
+
 	return nil
 }
 
 const goModTemplae = `
 module {{ .Repo }}
 
-go 1.13
+go {{ .GoVersion }}
+{{- if .Modules }}
+
+require (
+{{- range .Modules }}
+	{{ .Path }} {{ .Version }}
+{{- end }}
+)
+{{- end }}
 `
@@ -0,0 +1,85 @@
+package templates
+
+import (
+	"path/filepath"
+
+	"github.com/seamounts/kubeapi/pkg/model/file"
+)
+
+// GroupVersion is a group version of the project whose types are registered by the install package
+type GroupVersion struct {
+	// Package is the go package of the types of the group version
+	Package string
+	// ImportAlias is the name the package is imported with
+	ImportAlias string
+}
+
+// Install scaffolds the package that registers every group version of the project in a scheme
+type Install struct {
+	file.TemplateMixin
+
+	// GroupVersions are the group versions of the project, sorted by package
+	GroupVersions []GroupVersion
+}
+
+// GetBody implements Template
+func (f *Install) GetBody() string {
+	return f.TemplateBody
+}
+
+// GetTemplateName implements file.Overridable
+func (f *Install) GetTemplateName() string {
+	return "install"
+}
+
+func (f *Install) SetTemplateDefaults() error {
+	f.Path = filepath.Join("apis", "install", "install.go")
+
+	f.TemplateBody = installTemplate
+
+	// The file is maintained by the commands that add or remove group versions
+	f.IfExistsAction = file.Overwrite
+
+	return nil
+}
+
+const installTemplate = `
+// Package install registers every group version of the project in a scheme
+package install
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+{{- if .GroupVersions }}
+{{ range .GroupVersions }}
+	{{ .ImportAlias }} "{{ .Package }}"
+{{- end }}
+{{- end }}
+)
+
+var (
+	// Scheme contains every group version of the project
+	Scheme = runtime.NewScheme()
+	// Codecs provides the serializers of the types of Scheme
+	Codecs = serializer.NewCodecFactory(Scheme)
+	// ParameterCodec converts the options of the requests to and from query parameters
+	ParameterCodec = runtime.NewParameterCodec(Scheme)
+)
+
+var localSchemeBuilder = runtime.SchemeBuilder{
+{{- range .GroupVersions }}
+	{{ .ImportAlias }}.AddToScheme,
+{{- end }}
+}
+
+// AddToScheme adds every group version of the project to a scheme
+var AddToScheme = localSchemeBuilder.AddToScheme
+
+func init() {
+	metav1.AddToGroupVersion(Scheme, schema.GroupVersion{Version: "v1"})
+	utilruntime.Must(AddToScheme(Scheme))
+}
+`
@@ -0,0 +1,115 @@
+package templates
+
+import (
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+)
+
+// conditionsField is the field of the standard conditions of a status
+var conditionsField = resource.Field{
+	Name:     "conditions",
+	Type:     "[]metav1.Condition",
+	Optional: true,
+	Doc:      "Conditions are the latest available observations of the state of the object",
+	Markers:  []string{"listType=map", "listMapKey=type"},
+}
+
+// observedGenerationField is the field of a status with the generation its controller last observed
+var observedGenerationField = resource.Field{
+	Name:     "observedGeneration",
+	Type:     "int64",
+	Optional: true,
+	Doc:      "ObservedGeneration is the generation of the object the status was computed for",
+}
+
+// conditionsVersion is the oldest Kubernetes version with metav1.Condition
+const conditionsVersion = "1.19"
+
+// ageColumn is the printer column of the age of the objects, which kubectl prints by default
+const ageColumn = `kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"`
+
+// Presets returns the built-in presets of the types template
+func Presets() []resource.Preset {
+	return []resource.Preset{
+		{
+			Name:        "workload",
+			Description: "runs replicas of a pod template, with the status and scale subresources",
+			SpecFields: []resource.Field{
+				{
+					Name:     "replicas",
+					Type:     "int32",
+					Optional: true,
+					Doc:      "Replicas is the number of desired pods, 1 by default",
+					Markers:  []string{"kubebuilder:default=1", "kubebuilder:validation:Minimum=0"},
+				},
+				{
+					Name:     "selector",
+					Type:     "*metav1.LabelSelector",
+					Required: true,
+					Doc:      "Selector is the label query over the pods, which must match the labels of the template",
+				},
+				{
+					Name:     "template",
+					Type:     "corev1.PodTemplateSpec",
+					Required: true,
+					Doc:      "Template describes the pods that are created",
+				},
+			},
+			StatusFields: []resource.Field{
+				{
+					Name: "replicas",
+					Type: "int32",
+					Doc:  "Replicas is the number of pods targeted by the selector",
+				},
+				{
+					Name: "readyReplicas",
+					Type: "int32",
+					Doc:  "ReadyReplicas is the number of ready pods targeted by the selector",
+				},
+				{
+					Name: "selector",
+					Type: "string",
+					Doc:  "Selector is the serialized label query over the pods, used by the scale subresource",
+				},
+				observedGenerationField,
+				conditionsField,
+			},
+			Subresources: resource.Subresources{
+				Status: true,
+				Scale: &resource.ScaleSubresource{
+					SpecReplicasPath:   ".spec.replicas",
+					StatusReplicasPath: ".status.replicas",
+					LabelSelectorPath:  ".status.selector",
+				},
+			},
+			Markers: []string{
+				`kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=".spec.replicas"`,
+				`kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=".status.readyReplicas"`,
+				ageColumn,
+			},
+			MinKubernetesVersion: conditionsVersion,
+		},
+		{
+			Name:        "config",
+			Description: "holds configuration data, without status",
+			SpecFields: []resource.Field{
+				{
+					Name: "data",
+					Type: "map[string]string",
+					Doc:  "Data is the configuration data",
+				},
+			},
+			NoStatus: true,
+		},
+		{
+			Name:         "conditions",
+			Description:  "reports standard conditions and the observed generation, with the status subresource",
+			StatusFields: []resource.Field{observedGenerationField, conditionsField},
+			Subresources: resource.Subresources{Status: true},
+			Markers: []string{
+				`kubebuilder:printcolumn:name="Ready",type=string,JSONPath=".status.conditions[?(@.type==\"Ready\")].status"`,
+				ageColumn,
+			},
+			MinKubernetesVersion: conditionsVersion,
+		},
+	}
+}
@@ -0,0 +1,22 @@
+package templates
+
+import (
+	"github.com/seamounts/kubeapi/pkg/model/file"
+)
+
+var _ file.Template = &Raw{}
+
+// Raw scaffolds a file with contents that were not rendered from a template, e.g. by an external plugin
+type Raw struct {
+	file.TemplateMixin
+
+	// Contents are written as they are
+	Contents string
+}
+
+// SetTemplateDefaults implements input.Template
+func (f *Raw) SetTemplateDefaults() error {
+	f.TemplateBody = "{{ .Contents }}"
+
+	return nil
+}
@@ -10,6 +10,7 @@ type Register struct {
 	file.TemplateMixin
 	file.ResourceMixin
 	file.RepositoryMixin
+	file.RegistrationMixin
 }
 
 // GetBody implements Template
@@ -17,6 +18,11 @@ func (f *Register) GetBody() string {
 	return f.TemplateBody
 }
 
+// GetTemplateName implements file.Overridable
+func (f *Register) GetTemplateName() string {
+	return "register"
+}
+
 func (f *Register) SetTemplateDefaults() error {
 	f.Path = filepath.Join("apis", "%[group]", "%[version]", "register.go")
 	f.Path = f.Resource.Replacer().Replace(f.Path)
@@ -32,8 +38,14 @@ const registerTemplate = `
 package  {{ .Resource.Version }}
 
 import (
+{{- if .APIMachineryRegistration }}
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+{{- else }}
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/scheme"
+{{- end }}
 )
 
 // SchemeGroupVersion is group version used to register these objects
@@ -48,7 +60,22 @@ func Kind(kind string) schema.GroupKind {
 func Resource(resource string) schema.GroupResource {
 	return SchemeGroupVersion.WithResource(resource).GroupResource()
 }
+{{ if .APIMachineryRegistration }}
+var (
+	// SchemeBuilder collects the functions that add the types of this group version to a scheme,
+	// which the types files register in their init functions
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+
+	// AddToScheme is a global function that registers this API group & version to a scheme
+	AddToScheme = SchemeBuilder.AddToScheme
+)
 
+// addKnownTypes adds the types shared by every group version, e.g. the options of the requests, to the scheme
+func addKnownTypes(scheme *runtime.Scheme) error {
+	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
+	return nil
+}
+{{- else }}
 var (
 	// SchemeBuilder initializes a scheme builder
 	SchemeBuilder = &scheme.Builder{GroupVersion: SchemeGroupVersion}
@@ -56,4 +83,5 @@ var (
 	// AddToScheme is a global function that registers this API group & version to a scheme
 	AddToScheme = SchemeBuilder.AddToScheme
 )
+{{- end }}
 `
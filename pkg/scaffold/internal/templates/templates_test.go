@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templates_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/seamounts/kubeapi/pkg/model"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+	"github.com/seamounts/kubeapi/pkg/scaffold/internal/machinery"
+	"github.com/seamounts/kubeapi/pkg/scaffold/internal/templates"
+	"github.com/seamounts/kubeapi/pkg/scaffoldtest"
+)
+
+const boilerplate = `/*
+Copyright 2020 The Kubernetes Authors.
+*/`
+
+func newUniverse() *model.Universe {
+	cfg := &config.Config{
+		Version: config.Version1,
+		Domain:  "example.com",
+		Repo:    "example.com/project",
+	}
+	res := (&resource.Options{Group: "ship", Version: "v1beta1", Kind: "Frigate", Namespaced: true}).NewResource(cfg)
+
+	return model.NewUniverse(
+		model.WithConfig(cfg),
+		model.WithBoilerplate(boilerplate),
+		model.WithResource(res),
+	)
+}
+
+// fieldsResource returns the resource of newUniverse with spec and status fields
+func fieldsResource() *resource.Resource {
+	res := newUniverse().Resource
+	res.SpecFields = []resource.Field{
+		{Name: "replicas", Type: "int32", Required: true, Markers: []string{"kubebuilder:validation:Minimum=0"}},
+		{Name: "image", Type: "string", Doc: "Image is the container image of the crew.\nIt defaults to the latest one."},
+		{Name: "paused", Type: "bool", Optional: true},
+		{Name: "labels", Type: "map[string]string"},
+		{Name: "template", Type: "corev1.PodTemplateSpec"},
+		{Name: "ports", Type: "[]object", Fields: []resource.Field{
+			{Name: "name", Type: "string", Required: true},
+			{Name: "port", Type: "int32"},
+		}},
+	}
+	res.StatusFields = []resource.Field{
+		{Name: "readyReplicas", Type: "int32"},
+		{Name: "lastUpdateTime", Type: "metav1.Time", Optional: true},
+	}
+	return res
+}
+
+// presetResource returns the resource of newUniverse with the built-in preset named name
+func presetResource(name string) *resource.Resource {
+	preset, err := resource.FindPreset(templates.Presets(), name)
+	if err != nil {
+		panic(err)
+	}
+	opts := &resource.Options{Group: "ship", Version: "v1beta1", Kind: "Frigate", Namespaced: true}
+	opts.ApplyPreset(preset)
+	return opts.NewResource(newUniverse().Config)
+}
+
+func TestTemplates(t *testing.T) {
+	raw := &templates.Raw{Contents: "contents written as they are\n"}
+	raw.Path = "raw.txt"
+
+	for name, builder := range map[string]file.Template{
+		"boilerplate-apache2": &templates.Boilerplate{License: "apache2", Owner: "The Kubernetes Authors", Year: "2020"},
+		"boilerplate-none":    &templates.Boilerplate{License: "none", Owner: "The Kubernetes Authors", Year: "2020"},
+		"doc":                 &templates.Doc{},
+		"gitignore":           &templates.GitIgnore{},
+		"gomod":               &templates.GoMod{},
+		"install": &templates.Install{GroupVersions: []templates.GroupVersion{
+			{Package: "example.com/project/apis/ship/v1beta1", ImportAlias: "shipv1beta1"},
+			{Package: "example.com/project/apis/fleet/v1", ImportAlias: "fleetv1"},
+		}},
+		"gomod-kubernetes": &templates.GoMod{KubernetesVersionMixin: file.KubernetesVersionMixin{KubernetesVersion: "1.28"}},
+		"gomod-apimachinery": &templates.GoMod{
+			KubernetesVersionMixin: file.KubernetesVersionMixin{KubernetesVersion: "1.28"},
+			RegistrationMixin:      file.RegistrationMixin{Registration: config.RegistrationAPIMachinery},
+		},
+		"raw":      raw,
+		"register": &templates.Register{},
+		"register-apimachinery": &templates.Register{
+			RegistrationMixin: file.RegistrationMixin{Registration: config.RegistrationAPIMachinery},
+		},
+		"types":          &templates.Types{},
+		"types-workload": &templates.Types{ResourceMixin: file.ResourceMixin{Resource: presetResource("workload")}},
+		"types-config":   &templates.Types{ResourceMixin: file.ResourceMixin{Resource: presetResource("config")}},
+		"types-conditions": &templates.Types{
+			ResourceMixin: file.ResourceMixin{Resource: presetResource("conditions")},
+		},
+		"types-fields": &templates.Types{ResourceMixin: file.ResourceMixin{Resource: fieldsResource()}},
+		"types-apimachinery": &templates.Types{
+			RegistrationMixin: file.RegistrationMixin{Registration: config.RegistrationAPIMachinery},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewBasePathFs(afero.NewMemMapFs(), scaffoldtest.ProjectRoot)
+			if err := machinery.NewScaffold(file.Filesystem{FS: fs}).Execute(newUniverse(), builder); err != nil {
+				t.Fatal(err)
+			}
+
+			files, err := scaffoldtest.Files(fs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.ToSlash(builder.GetPath())
+			scaffoldtest.AssertGolden(t, filepath.Join("testdata", name), map[string]string{path: files[path]})
+		})
+	}
+}
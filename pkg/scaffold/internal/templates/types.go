@@ -10,11 +10,26 @@ type Types struct {
 	file.TemplateMixin
 	file.ResourceMixin
 	file.RepositoryMixin
+	file.RegistrationMixin
+
+	// SpecFields and StatusFields are the fields of the spec and of the status of the resource
+	SpecFields, StatusFields []TypeField
+	// Structs are the struct types of the nested fields
+	Structs []TypeStruct
+	// Imports are the import lines of the packages the fields reference
+	Imports []string
+	// Markers are the markers of the Kind, including the ones of its subresources, without their "+" prefix
+	Markers []string
 }
 
 // GetBody implements Template
-func (t *Types) GetBody() string {
-	return t.TemplateBody
+func (f *Types) GetBody() string {
+	return f.TemplateBody
+}
+
+// GetTemplateName implements file.Overridable
+func (f *Types) GetTemplateName() string {
+	return "types"
 }
 
 func (f *Types) SetTemplateDefaults() error {
@@ -25,6 +40,20 @@ func (f *Types) SetTemplateDefaults() error {
 
 	f.IfExistsAction = file.Overwrite
 
+	kind := f.Resource.Kind
+	f.Structs = nil
+	f.SpecFields = typeFields(kind, f.Resource.SpecFields, &f.Structs)
+	f.StatusFields = typeFields(kind, f.Resource.StatusFields, &f.Structs)
+	if err := checkStructs(f.Structs, kind, kind+"Spec", kind+"Status", kind+"List"); err != nil {
+		return err
+	}
+	imported := []string{"metav1"}
+	if f.APIMachineryRegistration() {
+		imported = append(imported, "runtime")
+	}
+	f.Imports = typeImports(imported, f.Resource.SpecFields, f.Resource.StatusFields)
+	f.Markers = append(f.Resource.Subresources.Markers(), f.Resource.Markers...)
+
 	return nil
 }
 
@@ -33,30 +62,65 @@ package {{ .Resource.Version }}
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+{{- if .APIMachineryRegistration }}
+	"k8s.io/apimachinery/pkg/runtime"
+{{- end }}
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
 )
 
 // +genclient
+{{- if not .Resource.Namespaced }}
+// +genclient:nonNamespaced
+{{- end }}
+{{- if .Resource.NoStatus }}
+// +genclient:noStatus
+{{- end }}
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+{{- range .Markers }}
+// +{{ . }}
+{{- end }}
 // {{ .Resource.Kind }} is a specification for a {{ .Resource.Kind }} resource
 type {{ .Resource.Kind }} struct {
 	metav1.TypeMeta   ` + "`" + `json:",inline"` + "`" + `
 	metav1.ObjectMeta ` + "`" + `json:"metadata,omitempty"` + "`" + `
-
+{{ if .Resource.NoStatus }}
+	Spec {{ .Resource.Kind }}Spec ` + "`" + `json:"spec,omitempty"` + "`" + `
+{{- else }}
 	Spec   {{ .Resource.Kind }}Spec    ` + "`" + `json:"spec,omitempty"` + "`" + `
 	Status {{ .Resource.Kind }}Status ` + "`" + `json:"status,omitempty"` + "`" + `
+{{- end }}
 }
 
 // {{ .Resource.Kind }}Spec is the spec for a {{ .Resource.Kind }} resource
 type {{ .Resource.Kind }}Spec struct {
+{{- if .SpecFields }}
+{{- template "fields" .SpecFields }}
+{{- else }}
 	// Foo is an example field of {{ .Resource.Kind }}. Edit {{ .Resource.Kind }}_types.go to remove/update
 	Foo string ` + "`" + `json:"foo,omitempty"` + "`" + `
+{{- end }}
 }
+{{- if not .Resource.NoStatus }}
 
 // {{ .Resource.Kind }}Status is the status for a {{ .Resource.Kind }} resource
 type {{ .Resource.Kind }}Status struct {
+{{- if .StatusFields }}
+{{- template "fields" .StatusFields }}
+{{- else }}
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+{{- end }}
 }
+{{- end }}
+{{- range .Structs }}
+
+// {{ .Doc }}
+type {{ .Name }} struct {
+{{- template "fields" .Fields }}
+}
+{{- end }}
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -68,6 +132,27 @@ type {{ .Resource.Kind }}List struct {
 }
 
 func init() {
+{{- if .APIMachineryRegistration }}
+	SchemeBuilder.Register(func(scheme *runtime.Scheme) error {
+		scheme.AddKnownTypes(SchemeGroupVersion, &{{ .Resource.Kind }}{}, &{{ .Resource.Kind }}List{})
+		return nil
+	})
+{{- else }}
 	SchemeBuilder.Register(&{{ .Resource.Kind }}{}, &{{ .Resource.Kind }}List{})
+{{- end }}
 }
+
+{{- define "fields" }}
+{{- range $i, $field := . }}
+{{- if $i }}
+{{ end }}
+{{- range .Doc }}
+	// {{ . }}
+{{- end }}
+{{- range .Markers }}
+	// +{{ . }}
+{{- end }}
+	{{ .Name }} {{ .Type }} ` + "`" + `json:"{{ .Tag }}"` + "`" + `
+{{- end }}
+{{- end }}
 `
@@ -0,0 +1,40 @@
+package scaffold
+
+import (
+	"io"
+	"os"
+)
+
+// Option configures a Scaffolder
+type Option func(*options)
+
+// options are the settings shared by the scaffolders
+type options struct {
+	// templatesDir is where the bodies of overridable templates are looked up
+	templatesDir string
+	// out is where the messages for users are written
+	out io.Writer
+}
+
+// WithTemplatesDir makes the Scaffolder look up the overridden template bodies in the provided
+// directory instead of .kubeapi/templates
+func WithTemplatesDir(dir string) Option {
+	return func(o *options) {
+		o.templatesDir = dir
+	}
+}
+
+// WithOutput makes the Scaffolder write its messages for users to out instead of the standard output
+func WithOutput(out io.Writer) Option {
+	return func(o *options) {
+		o.out = out
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{out: os.Stdout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,286 @@
+package scaffold
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/seamounts/kubeapi/pkg/model"
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+	"github.com/seamounts/kubeapi/pkg/scaffold/internal/machinery"
+	"github.com/seamounts/kubeapi/pkg/scaffold/internal/rewrite"
+	"github.com/seamounts/kubeapi/pkg/scaffold/internal/templates"
+)
+
+type renameKindScaffolder struct {
+	config *config.Config
+	from   *resource.Resource
+	to     *resource.Resource
+	fs     file.Filesystem
+	options
+}
+
+// NewRenameKindScaffolder returns a new Scaffolder that renames the kind of an existing API
+func NewRenameKindScaffolder(config *config.Config, from, to *resource.Resource, opts ...Option) Scaffolder {
+	return &renameKindScaffolder{
+		config:  config,
+		from:    from,
+		to:      to,
+		fs:      file.Filesystem{FS: afero.NewOsFs()},
+		options: newOptions(opts),
+	}
+}
+
+// InjectFS implements Scaffolder
+func (s *renameKindScaffolder) InjectFS(fs file.Filesystem) {
+	s.fs = fs
+}
+
+// Scaffold implements Scaffolder
+func (s *renameKindScaffolder) Scaffold() error {
+	fmt.Fprintf(s.out, "Renaming kind %s to %s...\n", s.from.Kind, s.to.Kind)
+	return s.scaffold()
+}
+
+func (s *renameKindScaffolder) scaffold() error {
+	dir := apiDir(s.from)
+
+	// The declarations of the other kinds of the group-version may be prefixed by the kind too (e.g. FrigateClass)
+	var siblings []string
+	for _, r := range s.config.Resources {
+		if r.Group == s.from.Group && r.Version == s.from.Version && r.Kind != s.from.Kind {
+			siblings = append(siblings, r.Kind)
+		}
+	}
+	names, err := rewrite.PrefixedDecls(s.fs.FS, dir, s.from.Kind, siblings...)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("kind %s is not declared in %s", s.from.Kind, dir)
+	}
+
+	// Check that none of the new names is already declared
+	existing, err := rewrite.PackageDecls(s.fs.FS, dir)
+	if err != nil {
+		return err
+	}
+	renames := make(map[string]string, len(names))
+	for _, name := range names {
+		renames[name] = s.to.Kind + strings.TrimPrefix(name, s.from.Kind)
+	}
+	if err := checkConflicts(existing, renames, dir); err != nil {
+		return err
+	}
+
+	if err := removeGenerated(s.fs.FS, s.from); err != nil {
+		return err
+	}
+
+	if err := rewrite.RenameIdents(s.fs.FS, dir, renames); err != nil {
+		return err
+	}
+
+	// Rename the types file after the new kind
+	typesFile, newTypesFile := typesPath(s.from), typesPath(s.to)
+	exists, err := afero.Exists(s.fs.FS, typesFile)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := s.fs.FS.Rename(typesFile, newTypesFile); err != nil {
+			return err
+		}
+	}
+
+	return rewrite.RewriteReferences(s.fs.FS, ".", s.from.Package, s.to.Package, s.to.ImportAlias, renames)
+}
+
+type moveKindScaffolder struct {
+	config      *config.Config
+	from        *resource.Resource
+	to          *resource.Resource
+	boilerplate string
+	fs          file.Filesystem
+	options
+}
+
+// NewMoveKindScaffolder returns a new Scaffolder that moves the kind of an existing API to another group-version
+func NewMoveKindScaffolder(config *config.Config, boilerplate string, from, to *resource.Resource,
+	opts ...Option) Scaffolder {
+	return &moveKindScaffolder{
+		config:      config,
+		from:        from,
+		to:          to,
+		boilerplate: boilerplate,
+		fs:          file.Filesystem{FS: afero.NewOsFs()},
+		options:     newOptions(opts),
+	}
+}
+
+// InjectFS implements Scaffolder
+func (s *moveKindScaffolder) InjectFS(fs file.Filesystem) {
+	s.fs = fs
+}
+
+// Scaffold implements Scaffolder
+func (s *moveKindScaffolder) Scaffold() error {
+	fmt.Fprintf(s.out, "Moving kind %s from %s/%s to %s/%s...\n",
+		s.from.Kind, s.from.Group, s.from.Version, s.to.Group, s.to.Version)
+	return s.scaffold()
+}
+
+func (s *moveKindScaffolder) scaffold() error {
+	typesFile, newTypesFile := typesPath(s.from), typesPath(s.to)
+
+	names, err := rewrite.FileDecls(s.fs.FS, typesFile)
+	if err != nil {
+		return fmt.Errorf("unable to read kind %s: %v", s.from.Kind, err)
+	}
+
+	// Scaffold the target group-version if it does not exist yet
+	toDir := apiDir(s.to)
+	var builders []file.Builder
+	for _, builder := range []file.Template{&templates.Doc{}, &templates.Register{}} {
+		model.NewUniverse(model.WithResource(s.to)).InjectInto(builder)
+		if err := builder.SetTemplateDefaults(); err != nil {
+			return err
+		}
+		exists, err := afero.Exists(s.fs.FS, builder.GetPath())
+		if err != nil {
+			return err
+		}
+		if !exists {
+			builders = append(builders, builder)
+		}
+	}
+
+	// Check that none of the moved names is already declared in the target group-version
+	renames := make(map[string]string, len(names))
+	for _, name := range names {
+		renames[name] = name
+	}
+	if len(builders) == 0 {
+		existing, err := rewrite.PackageDecls(s.fs.FS, toDir)
+		if err != nil {
+			return err
+		}
+		if err := checkConflicts(existing, renames, toDir); err != nil {
+			return err
+		}
+	} else {
+		// The install package registers the new group-version too
+		install, err := newInstall(s.fs.FS, s.config.Repo, s.to)
+		if err != nil {
+			return err
+		}
+		builders = append(builders, install)
+	}
+
+	// The kind is listed in the doc file of the target group-version instead of the one of the current group-version
+	builders = append(builders, &templates.DocKind{})
+	if err := machinery.NewScaffold(s.fs, machinery.TemplatesDir(s.templatesDir)).Execute(
+		model.NewUniverse(
+			model.WithConfig(s.config),
+			model.WithBoilerplate(s.boilerplate),
+			model.WithResource(s.to),
+		),
+		builders...,
+	); err != nil {
+		return err
+	}
+	if err := removeKindTag(s.fs.FS, s.from); err != nil {
+		return err
+	}
+
+	if err := removeGenerated(s.fs.FS, s.from); err != nil {
+		return err
+	}
+
+	if err := rewrite.MoveFile(s.fs.FS, typesFile, newTypesFile, s.to.Version); err != nil {
+		return err
+	}
+
+	return rewrite.RewriteReferences(s.fs.FS, ".", s.from.Package, s.to.Package, s.to.ImportAlias, renames)
+}
+
+// apiDir returns the directory that contains the types of the resource group-version
+func apiDir(res *resource.Resource) string {
+	return res.Replacer().Replace(filepath.Join("apis", "%[group]", "%[version]"))
+}
+
+// typesPath returns the path of the file that contains the types of the resource
+func typesPath(res *resource.Resource) string {
+	return res.Replacer().Replace(filepath.Join("apis", "%[group]", "%[version]", "%[kind]_types.go"))
+}
+
+// removeGenerated removes the generated code of a resource that would be left stale after
+// refactoring it, so that it can be regenerated from the new API types
+func removeGenerated(fs afero.Fs, res *resource.Resource) error {
+	paths := []string{
+		filepath.Join(apiDir(res), "zz_generated.deepcopy.go"),
+		res.Replacer().Replace(filepath.Join("client", "clientset", "versioned", "typed",
+			"%[group-package-name]", "%[version]", "%[kind].go")),
+		res.Replacer().Replace(filepath.Join("client", "clientset", "versioned", "typed",
+			"%[group-package-name]", "%[version]", "fake", "fake_%[kind].go")),
+		res.Replacer().Replace(filepath.Join("client", "listers",
+			"%[group-package-name]", "%[version]", "%[kind].go")),
+		res.Replacer().Replace(filepath.Join("client", "informers", "externalversions",
+			"%[group-package-name]", "%[version]", "%[kind].go")),
+	}
+
+	for _, path := range paths {
+		exists, err := afero.Exists(fs, path)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		if err := fs.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeKindTag removes the kind of a resource from the kinds listed in the doc file of its group-version
+func removeKindTag(fs afero.Fs, res *resource.Resource) error {
+	path := filepath.Join(apiDir(res), "doc.go")
+	contents, err := afero.ReadFile(fs, path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(fs, path, []byte(strings.Replace(string(contents), templates.KindTag(res.Kind), "", 1)), 0644)
+}
+
+// checkConflicts returns an error if any of the renamed identifiers is already declared
+func checkConflicts(existing []string, renames map[string]string, dir string) error {
+	declared := make(map[string]struct{}, len(existing))
+	for _, name := range existing {
+		declared[name] = struct{}{}
+	}
+	// Names that are being renamed can be reused
+	for from, to := range renames {
+		if from != to {
+			delete(declared, from)
+		}
+	}
+
+	for _, to := range renames {
+		if _, found := declared[to]; found {
+			return fmt.Errorf("%s is already declared in %s", to, dir)
+		}
+	}
+	return nil
+}
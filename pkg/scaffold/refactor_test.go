@@ -0,0 +1,285 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaffold_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/seamounts/kubeapi/pkg/model/config"
+	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+	"github.com/seamounts/kubeapi/pkg/scaffold"
+)
+
+// projectFiles are the files of a project with the Frigate and FrigateClass kinds of ship/v1beta1, which are
+// referenced from other packages with and without an import alias
+var projectFiles = map[string]string{
+	"apis/ship/v1beta1/doc.go": `// +kubeapi:kind=Frigate
+// +kubeapi:kind=FrigateClass
+// +kubeapi:scaffold:kinds
+// +groupName=ship.example.com
+package v1beta1
+`,
+	"apis/ship/v1beta1/frigate_types.go": `package v1beta1
+
+// Frigate is the Schema for the frigates API
+type Frigate struct {
+	Spec   FrigateSpec
+	Status FrigateStatus
+}
+
+// FrigateSpec is the spec for a Frigate resource
+type FrigateSpec struct{}
+
+// FrigateStatus is the status for a Frigate resource
+type FrigateStatus struct{}
+
+// FrigateList is a list of Frigate resources
+type FrigateList struct {
+	Items []Frigate
+}
+`,
+	"apis/ship/v1beta1/frigateclass_types.go": `package v1beta1
+
+// FrigateClass is the Schema for the frigateclasses API
+type FrigateClass struct{}
+
+// FrigateClassList is a list of FrigateClass resources
+type FrigateClassList struct {
+	Items []FrigateClass
+}
+`,
+	"apis/ship/v1beta1/zz_generated.deepcopy.go":                         "package v1beta1\n",
+	"client/clientset/versioned/typed/ship/v1beta1/frigate.go":           "package v1beta1\n",
+	"client/clientset/versioned/typed/ship/v1beta1/fake/fake_frigate.go": "package fake\n",
+	"client/listers/ship/v1beta1/frigate.go":                             "package v1beta1\n",
+	"client/informers/externalversions/ship/v1beta1/frigate.go":          "package v1beta1\n",
+	"controllers/frigate.go": `package controllers
+
+import (
+	shipv1beta1 "example.com/project/apis/ship/v1beta1"
+)
+
+// Class returns the FrigateClass of a Frigate
+func Class(f *shipv1beta1.Frigate) *shipv1beta1.FrigateClass {
+	return nil
+}
+`,
+	"pkg/fleet/fleet.go": `package fleet
+
+import "example.com/project/apis/ship/v1beta1"
+
+// Frigates are the Frigate resources of the fleet
+var Frigates v1beta1.FrigateList
+`,
+}
+
+// generatedFiles are the generated files of the Frigate kind of ship/v1beta1
+var generatedFiles = []string{
+	"apis/ship/v1beta1/zz_generated.deepcopy.go",
+	"client/clientset/versioned/typed/ship/v1beta1/frigate.go",
+	"client/clientset/versioned/typed/ship/v1beta1/fake/fake_frigate.go",
+	"client/listers/ship/v1beta1/frigate.go",
+	"client/informers/externalversions/ship/v1beta1/frigate.go",
+}
+
+// newRefactorProject returns the file system and the configuration of a project with projectFiles and files
+func newRefactorProject(t *testing.T, files map[string]string) (afero.Fs, *config.Config) {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	for _, contents := range []map[string]string{projectFiles, files} {
+		for path, body := range contents {
+			if err := afero.WriteFile(fs, path, []byte(body), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return fs, &config.Config{
+		Version: config.Version1,
+		Repo:    "example.com/project",
+		Domain:  "example.com",
+		Resources: []config.GVK{
+			{Group: "ship", Version: "v1beta1", Kind: "Frigate"},
+			{Group: "ship", Version: "v1beta1", Kind: "FrigateClass"},
+		},
+	}
+}
+
+// assertRefactor fails t if the files of fs do not contain the expected fragments or the removed files exist
+func assertRefactor(t *testing.T, fs afero.Fs, contains map[string][]string, removed []string) {
+	t.Helper()
+	for path, fragments := range contains {
+		contents, err := afero.ReadFile(fs, path)
+		if err != nil {
+			t.Errorf("unable to read %s: %v", path, err)
+			continue
+		}
+		for _, fragment := range fragments {
+			if !strings.Contains(string(contents), fragment) {
+				t.Errorf("%s does not contain %q:\n%s", path, fragment, contents)
+			}
+		}
+	}
+	for _, path := range removed {
+		if exists, _ := afero.Exists(fs, path); exists {
+			t.Errorf("%s was not removed", path)
+		}
+	}
+}
+
+func TestRenameKind(t *testing.T) {
+	for name, test := range map[string]struct {
+		files    map[string]string
+		contains map[string][]string
+		removed  []string
+		err      string
+	}{
+		"references": {
+			contains: map[string][]string{
+				"apis/ship/v1beta1/cruiser_types.go": {
+					"// Cruiser is the Schema for the frigates API",
+					"Spec   CruiserSpec",
+					"type CruiserStatus struct{}",
+					"Items []Cruiser\n",
+				},
+				// The declarations of the FrigateClass kind are not renamed
+				"apis/ship/v1beta1/frigateclass_types.go": {"type FrigateClass struct{}", "type FrigateClassList struct"},
+				"apis/ship/v1beta1/doc.go":                {"// +kubeapi:kind=Cruiser\n// +kubeapi:kind=FrigateClass\n"},
+				"controllers/frigate.go": {
+					`shipv1beta1 "example.com/project/apis/ship/v1beta1"`,
+					"func Class(f *shipv1beta1.Cruiser) *shipv1beta1.FrigateClass",
+				},
+				"pkg/fleet/fleet.go": {"var Frigates v1beta1.CruiserList"},
+			},
+			removed: append([]string{"apis/ship/v1beta1/frigate_types.go"}, generatedFiles...),
+		},
+		"name conflict": {
+			files: map[string]string{
+				"apis/ship/v1beta1/cruiser.go": "package v1beta1\n\ntype CruiserList struct{}\n",
+			},
+			err: "CruiserList is already declared in apis/ship/v1beta1",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs, cfg := newRefactorProject(t, test.files)
+			from := (&resource.Options{Group: "ship", Version: "v1beta1", Kind: "Frigate"}).NewResource(cfg)
+			to := (&resource.Options{Group: "ship", Version: "v1beta1", Kind: "Cruiser"}).NewResource(cfg)
+
+			s := scaffold.NewRenameKindScaffolder(cfg, from, to, scaffold.WithOutput(ioutil.Discard))
+			s.InjectFS(file.Filesystem{FS: fs})
+			err := s.Scaffold()
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected an error with %q, got %v", test.err, err)
+				}
+				// Nothing is changed before the conflict is found
+				assertRefactor(t, fs, map[string][]string{
+					"apis/ship/v1beta1/frigate_types.go": {"type Frigate struct"},
+				}, nil)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertRefactor(t, fs, test.contains, test.removed)
+		})
+	}
+}
+
+func TestMoveKind(t *testing.T) {
+	fleetV1 := map[string]string{
+		"apis/fleet/v1/doc.go":      "// +kubeapi:scaffold:kinds\n// +groupName=fleet.example.com\npackage v1\n",
+		"apis/fleet/v1/register.go": "package v1\n",
+	}
+
+	for name, test := range map[string]struct {
+		files    map[string]string
+		contains map[string][]string
+		removed  []string
+		err      string
+	}{
+		"new group-version": {
+			contains: map[string][]string{
+				"apis/fleet/v1/frigate_types.go": {"package v1\n", "type FrigateList struct"},
+				"apis/fleet/v1/doc.go":           {"// +kubeapi:kind=Frigate\n", "// +groupName=fleet.example.com"},
+				"apis/ship/v1beta1/doc.go":       {"// +groupName=ship.example.com\npackage v1beta1\n"},
+				"apis/fleet/v1/register.go":      {"package v1\n"},
+				"apis/install/install.go":        {`"example.com/project/apis/fleet/v1"`},
+				"controllers/frigate.go": {
+					`fleetv1 "example.com/project/apis/fleet/v1"`,
+					`shipv1beta1 "example.com/project/apis/ship/v1beta1"`,
+					"func Class(f *fleetv1.Frigate) *shipv1beta1.FrigateClass",
+				},
+				// The package that is no longer used is not imported anymore
+				"pkg/fleet/fleet.go": {"import (\n\tfleetv1 \"example.com/project/apis/fleet/v1\"\n)\n",
+					"var Frigates fleetv1.FrigateList"},
+			},
+			removed: append([]string{"apis/ship/v1beta1/frigate_types.go"}, generatedFiles...),
+		},
+		"existing group-version": {
+			files: fleetV1,
+			contains: map[string][]string{
+				"apis/fleet/v1/frigate_types.go": {"package v1\n"},
+				// The files of the existing group-version are not scaffolded again
+				"apis/fleet/v1/register.go": {"package v1\n"},
+				"apis/fleet/v1/doc.go":      {"// +kubeapi:kind=Frigate\n// +kubeapi:scaffold:kinds\n"},
+				"apis/ship/v1beta1/doc.go":  {"// +kubeapi:kind=FrigateClass\n// +kubeapi:scaffold:kinds\n"},
+			},
+			removed: []string{"apis/install/install.go", "apis/ship/v1beta1/frigate_types.go"},
+		},
+		"name conflict": {
+			files: map[string]string{
+				"apis/fleet/v1/doc.go":      fleetV1["apis/fleet/v1/doc.go"],
+				"apis/fleet/v1/register.go": fleetV1["apis/fleet/v1/register.go"],
+				"apis/fleet/v1/fleet.go":    "package v1\n\ntype FrigateList struct{}\n",
+			},
+			err: "FrigateList is already declared in apis/fleet/v1",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs, cfg := newRefactorProject(t, test.files)
+			from := (&resource.Options{Group: "ship", Version: "v1beta1", Kind: "Frigate"}).NewResource(cfg)
+			to := (&resource.Options{Group: "fleet", Version: "v1", Kind: "Frigate"}).NewResource(cfg)
+
+			s := scaffold.NewMoveKindScaffolder(cfg, "", from, to, scaffold.WithOutput(ioutil.Discard))
+			s.InjectFS(file.Filesystem{FS: fs})
+			err := s.Scaffold()
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected an error with %q, got %v", test.err, err)
+				}
+				// Nothing is changed before the conflict is found
+				assertRefactor(t, fs, map[string][]string{
+					"apis/ship/v1beta1/frigate_types.go": {"type Frigate struct"},
+				}, []string{"apis/fleet/v1/frigate_types.go"})
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertRefactor(t, fs, test.contains, test.removed)
+			// The kind is no longer listed in the doc file of the group-version it was moved from
+			if doc, _ := afero.ReadFile(fs, "apis/ship/v1beta1/doc.go"); strings.Contains(string(doc), "=Frigate\n") {
+				t.Errorf("the kind is still listed in the doc file:\n%s", doc)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package scaffold
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/afero"
+
+	"github.com/seamounts/kubeapi/pkg/model/file"
+	"github.com/seamounts/kubeapi/pkg/model/resource"
+	"github.com/seamounts/kubeapi/pkg/scaffold/internal/machinery"
+	"github.com/seamounts/kubeapi/pkg/scaffold/internal/templates"
+)
+
+// DefaultTemplatesDir is the directory where the bodies of overridable templates are looked up
+const DefaultTemplatesDir = machinery.DefaultTemplatesDir
+
+type exportTemplatesScaffolder struct {
+	dir   string
+	force bool
+	fs    file.Filesystem
+	options
+}
+
+// NewExportTemplatesScaffolder returns a new Scaffolder that writes the built-in bodies of the overridable
+// templates to dir, as a starting point to override them
+func NewExportTemplatesScaffolder(dir string, force bool, opts ...Option) Scaffolder {
+	return &exportTemplatesScaffolder{
+		dir:     dir,
+		force:   force,
+		fs:      file.Filesystem{FS: afero.NewOsFs()},
+		options: newOptions(opts),
+	}
+}
+
+// InjectFS implements Scaffolder
+func (s *exportTemplatesScaffolder) InjectFS(fs file.Filesystem) {
+	s.fs = fs
+}
+
+// Scaffold implements Scaffolder
+func (s *exportTemplatesScaffolder) Scaffold() error {
+	fmt.Fprintf(s.out, "Exporting templates to %s...\n", s.dir)
+
+	defaults := templates.Defaults()
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if err := s.fs.FS.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	for _, name := range names {
+		path := filepath.Join(s.dir, name+".tmpl")
+		exists, err := afero.Exists(s.fs.FS, path)
+		if err != nil {
+			return err
+		}
+		if exists && !s.force {
+			s.record(file.Change{Path: path, Action: file.Skipped})
+			continue
+		}
+
+		change := file.Change{Path: path, Action: file.Created, Contents: defaults[name]}
+		if exists {
+			previous, err := afero.ReadFile(s.fs.FS, path)
+			if err != nil {
+				return err
+			}
+			change.Action, change.Previous = file.Updated, string(previous)
+			if change.Previous == change.Contents {
+				change.Action = file.Unchanged
+			}
+		}
+
+		if err := afero.WriteFile(s.fs.FS, path, []byte(defaults[name]), 0644); err != nil {
+			return err
+		}
+		s.record(change)
+	}
+
+	return nil
+}
+
+func (s *exportTemplatesScaffolder) record(change file.Change) {
+	if s.fs.Record != nil {
+		s.fs.Record(change)
+	}
+}
+
+// Presets returns the built-in presets of Kinds, which plugins may complement with plugin.PresetProvider
+func Presets() []resource.Preset {
+	return templates.Presets()
+}
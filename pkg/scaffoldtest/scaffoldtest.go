@@ -0,0 +1,228 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scaffoldtest runs the commands of the cli against in-memory projects and compares the files
+// they scaffold with golden files, so that plugins and templates can be tested without touching the disk.
+//
+// A typical test of a plugin runs its commands and compares the project files with a golden directory:
+//
+//	p := scaffoldtest.NewProject(cli.WithPlugins(&myplugin.Plugin{}), cli.WithDefaultPlugin(&myplugin.Plugin{}))
+//	if _, err := p.Run("init", "--repo", "example.com/project"); err != nil {
+//		t.Fatal(err)
+//	}
+//	res, err := p.Run("create", "api", "--group", "ship", "--version", "v1", "--kind", "Frigate")
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	scaffoldtest.AssertGolden(t, filepath.Join("testdata", "create-api"), res.Files)
+//
+// Running the tests with the -update flag rewrites the golden files instead of comparing them.
+package scaffoldtest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	internalconfig "github.com/seamounts/kubeapi/internal/config"
+	"github.com/seamounts/kubeapi/internal/diff"
+	"github.com/seamounts/kubeapi/pkg/cli"
+	"github.com/seamounts/kubeapi/pkg/plugin"
+)
+
+// ProjectRoot is the path in-memory projects are rooted at. Its base name is the name of the project.
+const ProjectRoot = "/project"
+
+// GoldenSuffix is appended to the paths of the golden files, so that golden go.mod or .gitignore files
+// do not affect the repository they are stored in
+const GoldenSuffix = ".golden"
+
+var update = flag.Bool("update", false, "update the golden files instead of comparing them")
+
+// Project is an in-memory project the commands of a cli are run against
+type Project struct {
+	fs   afero.Fs
+	opts []cli.Option
+}
+
+// Result is the outcome of a command run against a Project
+type Result struct {
+	// Output is what the command wrote to its standard output and error
+	Output string
+	// Files are the contents of the project files after the command ran, by slash-separated path
+	Files map[string]string
+	// Config is the content of the PROJECT file after the command ran, empty if there is none
+	Config string
+}
+
+// NewProject returns an empty in-memory project. Its commands are run by a cli configured with opts,
+// which usually provide the plugins under test.
+func NewProject(opts ...cli.Option) *Project {
+	return &Project{
+		fs:   afero.NewBasePathFs(afero.NewMemMapFs(), ProjectRoot),
+		opts: opts,
+	}
+}
+
+// FS returns the file system of the project, e.g. to add files before running a command
+func (p *Project) FS() afero.Fs {
+	return p.fs
+}
+
+// Run runs the cli with the provided arguments, without the command name, against the project.
+// The result is returned even if the command fails, so that its output can be checked.
+func (p *Project) Run(args ...string) (*Result, error) {
+	return p.run(plugin.Runtime{}, args...)
+}
+
+// RunInteractive runs the cli like Run, answering its prompts with the lines of answers.
+func (p *Project) RunInteractive(answers string, args ...string) (*Result, error) {
+	return p.run(plugin.Runtime{Stdin: strings.NewReader(answers), Interactive: true}, args...)
+}
+
+// run runs the cli in rt, with the output and the file system of the project
+func (p *Project) run(rt plugin.Runtime, args ...string) (*Result, error) {
+	out := &bytes.Buffer{}
+	rt.Stdout, rt.Stderr, rt.FS, rt.ProjectRoot = out, out, p.fs, ProjectRoot
+	opts := append([]cli.Option{}, p.opts...)
+	opts = append(opts,
+		cli.WithArgs(args...),
+		cli.WithRuntime(rt),
+	)
+
+	c, err := cli.New(opts...)
+	if err == nil {
+		err = c.Run()
+	}
+
+	res := &Result{Output: out.String()}
+	var filesErr error
+	if res.Files, filesErr = p.Files(); filesErr != nil && err == nil {
+		err = filesErr
+	}
+	res.Config = res.Files[internalconfig.DefaultPath]
+	return res, err
+}
+
+// Files returns the contents of the project files, by slash-separated path
+func (p *Project) Files() (map[string]string, error) {
+	return Files(p.fs)
+}
+
+// Files returns the contents of the files in fs, by slash-separated path relative to its root
+func Files(fs afero.Fs) (map[string]string, error) {
+	files := make(map[string]string)
+	err := afero.Walk(fs, ".", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		contents, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(path)] = string(contents)
+		return nil
+	})
+	return files, err
+}
+
+// AssertGolden fails t if files, by slash-separated path, differ from the golden files in dir, which are
+// named after those paths with the GoldenSuffix. With the -update flag, the golden files are rewritten.
+func AssertGolden(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+
+	if *update {
+		if err := writeGolden(dir, files); err != nil {
+			t.Fatalf("unable to update the golden files in %s: %v", dir, err)
+		}
+		return
+	}
+
+	golden, err := readGolden(dir)
+	if err != nil {
+		t.Fatalf("unable to read the golden files in %s (run the tests with -update to create them): %v", dir, err)
+	}
+
+	paths := make([]string, 0, len(files)+len(golden))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	for path := range golden {
+		if _, found := files[path]; !found {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		want, expected := golden[path]
+		got, produced := files[path]
+		switch {
+		case !expected:
+			t.Errorf("unexpected file %s", path)
+		case !produced:
+			t.Errorf("missing file %s", path)
+		case got != want:
+			t.Errorf("file %s differs from its golden file:\n%s", path, diff.Unified(path, want, got))
+		}
+	}
+}
+
+// readGolden returns the contents of the golden files in dir, by the slash-separated path they stand for
+func readGolden(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	fs := afero.NewOsFs()
+	err := afero.Walk(fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, GoldenSuffix) {
+			return err
+		}
+		contents, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, strings.TrimSuffix(path, GoldenSuffix))
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(contents)
+		return nil
+	})
+	return files, err
+}
+
+// writeGolden replaces the golden files in dir with files
+func writeGolden(dir string, files map[string]string) error {
+	fs := afero.NewOsFs()
+	if err := fs.RemoveAll(dir); err != nil {
+		return err
+	}
+	for path, contents := range files {
+		golden := filepath.Join(dir, filepath.FromSlash(path)+GoldenSuffix)
+		if err := fs.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			return err
+		}
+		if err := afero.WriteFile(fs, golden, []byte(contents), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}